@@ -0,0 +1,201 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+)
+
+func (cfg *apiConfig) handlerListReports(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Reports    []database.GetReportsPageRow `json:"reports"`
+		NextCursor string                       `json:"next_cursor,omitempty"`
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = reportStatusOpen
+	}
+
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	reports, err := cfg.db.GetReportsPage(r.Context(), database.GetReportsPageParams{
+		Status:          status,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageSize:        page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get reports", err)
+		return
+	}
+	if reports == nil {
+		reports = []database.GetReportsPageRow{}
+	}
+
+	nextCursor := ""
+	if int32(len(reports)) > page.Limit {
+		reports = reports[:page.Limit]
+		last := reports[len(reports)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Reports:    reports,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerApproveReport(w http.ResponseWriter, r *http.Request) {
+	cfg.resolveReport(w, r, reportStatusApproved)
+}
+
+func (cfg *apiConfig) handlerHideReportedChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.resolveReport(w, r, reportStatusHidden)
+}
+
+func (cfg *apiConfig) handlerDeleteReportedChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.resolveReport(w, r, reportStatusDeleted)
+}
+
+// resolveReport applies a moderator's decision to the reported chirp and
+// closes every open report against it.
+func (cfg *apiConfig) resolveReport(w http.ResponseWriter, r *http.Request, status string) {
+	moderatorID := auth.PrincipalFromContext(r.Context()).UserID
+
+	reportID, err := uuid.Parse(r.PathValue("reportId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid report ID", err)
+		return
+	}
+
+	report, err := cfg.db.GetReportById(r.Context(), reportID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find report", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't find report", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	switch status {
+	case reportStatusApproved:
+		err = qtx.SetChirpHidden(r.Context(), database.SetChirpHiddenParams{
+			ID:       report.ChirpID,
+			IsHidden: false,
+		})
+	case reportStatusHidden:
+		err = qtx.SetChirpHidden(r.Context(), database.SetChirpHiddenParams{
+			ID:       report.ChirpID,
+			IsHidden: true,
+		})
+	case reportStatusDeleted:
+		// Tombstone rather than delete so the report keeps pointing at
+		// something and replies keep their parent.
+		err = qtx.DeleteRechirpsOf(r.Context(), report.ChirpID)
+		if err == nil {
+			err = qtx.TombstoneChirp(r.Context(), report.ChirpID)
+		}
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+
+	err = qtx.ResolveReportsForChirp(r.Context(), database.ResolveReportsForChirpParams{
+		ChirpID:    report.ChirpID,
+		Status:     status,
+		ResolvedBy: uuid.NullUUID{UUID: moderatorID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resolve reports", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerSuspendUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setUserSuspended(w, r, true)
+}
+
+func (cfg *apiConfig) handlerUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setUserSuspended(w, r, false)
+}
+
+func (cfg *apiConfig) setUserSuspended(w http.ResponseWriter, r *http.Request, suspended bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	// Moderators can only act on users below them, so they can't lock out
+	// each other or the admins who manage roles.
+	principal := auth.PrincipalFromContext(r.Context())
+	if userID == principal.UserID {
+		respondWithError(w, http.StatusForbidden, "You can't suspend yourself", nil)
+		return
+	}
+	target, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if !principal.Outranks(target.Role) {
+		respondWithError(w, http.StatusForbidden, "You can only suspend users with a lower role", nil)
+		return
+	}
+
+	updated, err := cfg.db.SetUserSuspended(r.Context(), database.SetUserSuspendedParams{
+		ID:          userID,
+		IsSuspended: suspended,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", nil)
+		return
+	}
+
+	// Suspended users are logged out everywhere; their access tokens expire
+	// on their own and can't be used to post in the meantime.
+	if suspended {
+		err = cfg.db.RevokeAllRefreshTokensForUser(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't revoke sessions", err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+	"github.com/pderyuga/chirpy-go/internal/entities"
+)
+
+// saveChirpEntities records the hashtags and mentions in a new chirp so they
+// can be looked up without scanning chirp bodies.
+func saveChirpEntities(ctx context.Context, db *database.Queries, chirp database.Chirp) error {
+	found := entities.Extract(chirp.Body)
+
+	tags := entities.Hashtags(found)
+	if len(tags) > 0 {
+		err := db.AddChirpHashtags(ctx, database.AddChirpHashtagsParams{
+			ChirpID: chirp.ID,
+			Tags:    tags,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Mentions are by handle, or by email address for users without one.
+	emails := []string{}
+	handles := []string{}
+	for _, mention := range entities.Mentions(found) {
+		if strings.Contains(mention, "@") {
+			emails = append(emails, mention)
+		} else {
+			handles = append(handles, mention)
+		}
+	}
+
+	userIDs := []uuid.UUID{}
+	if len(emails) > 0 {
+		ids, err := db.GetUserIdsByEmails(ctx, emails)
+		if err != nil {
+			return err
+		}
+		userIDs = append(userIDs, ids...)
+	}
+	if len(handles) > 0 {
+		ids, err := db.GetUserIdsByHandles(ctx, handles)
+		if err != nil {
+			return err
+		}
+		userIDs = append(userIDs, ids...)
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	return db.AddChirpMentions(ctx, database.AddChirpMentionsParams{
+		ChirpID: chirp.ID,
+		UserIds: userIDs,
+	})
+}
+
+func (cfg *apiConfig) handlerGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []chirpResponse `json:"chirps"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+	}
+
+	viewerID := auth.PrincipalFromContext(r.Context()).UserID
+
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid hashtag", nil)
+		return
+	}
+
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	chirps, err := cfg.db.GetChirpsForHashtagPage(r.Context(), database.GetChirpsForHashtagPageParams{
+		Tag:             tag,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageSize:        page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps for hashtag", err)
+		return
+	}
+
+	chirps, nextCursor := paginateChirps(chirps, page)
+
+	responses, err := cfg.chirpResponses(r.Context(), chirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps for hashtag", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     responses,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerGetMyMentions(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []chirpResponse `json:"chirps"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+	}
+
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	chirps, err := cfg.db.GetMentionsPage(r.Context(), database.GetMentionsPageParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageSize:        page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get mentions", err)
+		return
+	}
+
+	chirps, nextCursor := paginateChirps(chirps, page)
+
+	responses, err := cfg.chirpResponses(r.Context(), chirps, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get mentions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     responses,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/database"
+	"github.com/pderyuga/chirpy-go/internal/entities"
+)
+
+type chirpResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	UserID    uuid.UUID  `json:"user_id"`
+	InReplyTo *uuid.UUID `json:"in_reply_to"`
+	IsDeleted bool       `json:"is_deleted"`
+	IsHidden  bool       `json:"is_hidden"`
+	RechirpOf *uuid.UUID `json:"rechirp_of"`
+	QuoteOf   *uuid.UUID `json:"quote_of"`
+	LikeCount int64      `json:"like_count"`
+	LikedByMe *bool      `json:"liked_by_me,omitempty"`
+
+	// Entities are the hashtags and mentions in Body, for clients to linkify.
+	Entities []entities.Entity `json:"entities"`
+
+	// ReferencedChirp is the rechirped or quoted chirp, rendered inline.
+	ReferencedChirp *chirpResponse `json:"referenced_chirp,omitempty"`
+}
+
+// chirpResponses converts chirps into their API representation, loading like
+// counts and rechirped or quoted chirps for the whole batch at once. viewerID
+// is uuid.Nil for anonymous requests, in which case liked_by_me is left out.
+func (cfg *apiConfig) chirpResponses(ctx context.Context, chirps []database.Chirp, viewerID uuid.UUID) ([]chirpResponse, error) {
+	responses, err := cfg.buildChirpResponses(ctx, chirps, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	referencedIDs := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if chirp.RechirpOf.Valid {
+			referencedIDs = append(referencedIDs, chirp.RechirpOf.UUID)
+		}
+		if chirp.QuoteOf.Valid {
+			referencedIDs = append(referencedIDs, chirp.QuoteOf.UUID)
+		}
+	}
+	if len(referencedIDs) == 0 {
+		return responses, nil
+	}
+
+	referencedChirps, err := cfg.db.GetChirpsByIds(ctx, referencedIDs)
+	if err != nil {
+		return nil, err
+	}
+	// Referenced chirps are only expanded one level deep.
+	referencedResponses, err := cfg.buildChirpResponses(ctx, referencedChirps, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[uuid.UUID]*chirpResponse, len(referencedResponses))
+	for i := range referencedResponses {
+		referenced[referencedResponses[i].ID] = &referencedResponses[i]
+	}
+
+	for i, chirp := range chirps {
+		if chirp.RechirpOf.Valid {
+			responses[i].ReferencedChirp = referenced[chirp.RechirpOf.UUID]
+		}
+		if chirp.QuoteOf.Valid {
+			responses[i].ReferencedChirp = referenced[chirp.QuoteOf.UUID]
+		}
+	}
+
+	return responses, nil
+}
+
+func (cfg *apiConfig) buildChirpResponses(ctx context.Context, chirps []database.Chirp, viewerID uuid.UUID) ([]chirpResponse, error) {
+	responses := make([]chirpResponse, 0, len(chirps))
+	if len(chirps) == 0 {
+		return responses, nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+
+	likeCountRows, err := cfg.db.GetLikeCounts(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	likeCounts := make(map[uuid.UUID]int64, len(likeCountRows))
+	for _, row := range likeCountRows {
+		likeCounts[row.ChirpID] = row.LikeCount
+	}
+
+	var liked map[uuid.UUID]bool
+	if viewerID != uuid.Nil {
+		likedIDs, err := cfg.db.GetLikedChirpIds(ctx, database.GetLikedChirpIdsParams{
+			UserID:   viewerID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		liked = make(map[uuid.UUID]bool, len(likedIDs))
+		for _, id := range likedIDs {
+			liked[id] = true
+		}
+	}
+
+	for _, chirp := range chirps {
+		response := chirpResponse{
+			ID:        chirp.ID,
+			CreatedAt: chirp.CreatedAt,
+			UpdatedAt: chirp.UpdatedAt,
+			Body:      chirp.Body,
+			UserID:    chirp.UserID,
+			InReplyTo: nullUUIDPointer(chirp.InReplyTo),
+			IsDeleted: chirp.IsDeleted,
+			IsHidden:  chirp.IsHidden,
+			RechirpOf: nullUUIDPointer(chirp.RechirpOf),
+			QuoteOf:   nullUUIDPointer(chirp.QuoteOf),
+			LikeCount: likeCounts[chirp.ID],
+			Entities:  entities.Extract(chirp.Body),
+		}
+		// Chirps hidden by a moderator stay visible to their author only.
+		if chirp.IsHidden && chirp.UserID != viewerID {
+			response.Body = ""
+			response.Entities = []entities.Entity{}
+		}
+		if liked != nil {
+			likedByMe := liked[chirp.ID]
+			response.LikedByMe = &likedByMe
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+func (cfg *apiConfig) chirpResponse(ctx context.Context, chirp database.Chirp, viewerID uuid.UUID) (chirpResponse, error) {
+	responses, err := cfg.chirpResponses(ctx, []database.Chirp{chirp}, viewerID)
+	if err != nil {
+		return chirpResponse{}, err
+	}
+	return responses[0], nil
+}
+
+func nullUUIDPointer(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
@@ -1,36 +1,34 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"slices"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/pderyuga/chirpy-go/internal/auth"
 	"github.com/pderyuga/chirpy-go/internal/database"
+	"github.com/pderyuga/chirpy-go/internal/moderation"
 )
 
 func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body      string     `json:"body"`
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
+		QuoteOf   *uuid.UUID `json:"quote_of"`
 	}
 
-	bearerToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
-		return
-	}
-	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+	if !requireVerifiedEmail(w, r) {
 		return
 	}
 
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	err = decoder.Decode(&params)
+	err := decoder.Decode(&params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
 		return
@@ -41,41 +39,101 @@ func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
+	moderated := cfg.moderation.Check(params.Body)
+	if moderated.Action == moderation.Reject {
+		type rejectedResponse struct {
+			Error   string   `json:"error"`
+			Reasons []string `json:"reasons"`
+		}
+		respondWithJSON(w, http.StatusUnprocessableEntity, rejectedResponse{
+			Error:   "Chirp was rejected by moderation",
+			Reasons: moderated.Reasons,
+		})
+		return
+	}
+
+	inReplyTo := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		parent, err := cfg.db.GetChirpById(r.Context(), *params.InReplyTo)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp to reply to", err)
+			return
+		}
+		if err != nil || parent.IsDeleted {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp to reply to", err)
+			return
+		}
+		inReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
 	}
 
-	cleanedBody := getCleanedBody(params.Body, badWords)
+	quoteOf := uuid.NullUUID{}
+	if params.QuoteOf != nil {
+		quoted, err := cfg.getOriginalChirp(r.Context(), *params.QuoteOf)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp to quote", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp to quote", err)
+			return
+		}
+		quoteOf = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
 
 	newParams := database.CreateChirpParams{
-		Body:   cleanedBody,
-		UserID: userID,
+		Body:      moderated.Body,
+		UserID:    userID,
+		InReplyTo: inReplyTo,
+		QuoteOf:   quoteOf,
 	}
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), newParams)
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
 
-	w.Header().Set("Content-Type", "application/json")
-	respondWithJSON(w, http.StatusCreated, chirp)
-}
+	chirp, err := qtx.CreateChirp(r.Context(), newParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
 
-func getCleanedBody(chirp string, badWords map[string]struct{}) string {
-	placeholder := "****"
-	words := strings.Split(chirp, " ")
+	err = saveChirpEntities(r.Context(), qtx, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
 
-	for i, word := range words {
-		lowercaseWord := strings.ToLower(word)
-		if _, ok := badWords[lowercaseWord]; ok {
-			words[i] = placeholder
+	// Flagged chirps are published but queued for a moderator, as if
+	// reported by the system.
+	if moderated.Action == moderation.Flag {
+		_, err = qtx.CreateReport(r.Context(), database.CreateReportParams{
+			ChirpID: chirp.ID,
+			Reason:  "Flagged by moderation: " + strings.Join(moderated.Reasons, ", "),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+			return
 		}
 	}
-	cleanedBody := strings.Join(words, " ")
-	return cleanedBody
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	response, err := cfg.chirpResponse(r.Context(), chirp, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	respondWithJSON(w, http.StatusCreated, response)
 }
 
 func authorIDFromRequest(r *http.Request) (uuid.UUID, error) {
@@ -91,46 +149,66 @@ func authorIDFromRequest(r *http.Request) (uuid.UUID, error) {
 }
 
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []chirpResponse `json:"chirps"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+	}
+
+	viewerID := auth.PrincipalFromContext(r.Context()).UserID
+
 	authorID, err := authorIDFromRequest(r)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid author ID", err)
 		return
 	}
 
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	nullAuthorID := uuid.NullUUID{UUID: authorID, Valid: authorID != uuid.Nil}
+
 	var chirps []database.Chirp
 
-	if authorID != uuid.Nil {
-		chirps, err = cfg.db.GetChirpsForAuthorId(r.Context(), authorID)
+	if r.URL.Query().Get("sort") == "desc" {
+		chirps, err = cfg.db.GetChirpsPageDesc(r.Context(), database.GetChirpsPageDescParams{
+			AuthorID:        nullAuthorID,
+			CursorCreatedAt: page.cursorCreatedAt(),
+			CursorID:        page.cursorID(),
+			PageSize:        page.queryLimit(),
+		})
 	} else {
-		chirps, err = cfg.db.GetChirps(r.Context())
+		chirps, err = cfg.db.GetChirpsPageAsc(r.Context(), database.GetChirpsPageAscParams{
+			AuthorID:        nullAuthorID,
+			CursorCreatedAt: page.cursorCreatedAt(),
+			CursorID:        page.cursorID(),
+			PageSize:        page.queryLimit(),
+		})
 	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
 
-	sortDirection := "asc"
-	sortDirectionParam := r.URL.Query().Get("sort")
-	if sortDirectionParam == "desc" {
-		sortDirection = "desc"
-	}
-	
-	if sortDirection == "desc" {
-		slices.SortFunc(chirps, func(a, b database.Chirp) int {
-			if a.CreatedAt.After(b.CreatedAt) {
-				return -1
-			}
-			if a.CreatedAt.Before(b.CreatedAt) {
-				return 1
-			}
-			return 0
-		})
+	chirps, nextCursor := paginateChirps(chirps, page)
+
+	responses, err := cfg.chirpResponses(r.Context(), chirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, chirps)
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     responses,
+		NextCursor: nextCursor,
+	})
 }
 
 func (cfg *apiConfig) handlerGetChirpById(w http.ResponseWriter, r *http.Request) {
+	viewerID := auth.PrincipalFromContext(r.Context()).UserID
+
 	// http.Request.PathValue() returns a stirng
 	chirpIdString := r.PathValue("chirpId")
 	// Parse the string into a UUID
@@ -145,23 +223,28 @@ func (cfg *apiConfig) handlerGetChirpById(w http.ResponseWriter, r *http.Request
 		respondWithError(w, http.StatusNotFound, err.Error(), err)
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	respondWithJSON(w, http.StatusOK, chirp)
-}
-
-func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
-	bearerToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
+	if chirp.IsDeleted {
+		respondWithError(w, http.StatusNotFound, "Chirp has been deleted", nil)
 		return
 	}
-	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if chirp.IsHidden && chirp.UserID != viewerID {
+		respondWithError(w, http.StatusNotFound, "Chirp has been hidden by a moderator", nil)
+		return
+	}
+
+	response, err := cfg.chirpResponse(r.Context(), chirp, viewerID)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+
 	// http.Request.PathValue() returns a stirng
 	chirpIdString := r.PathValue("chirpId")
 	// Parse the string into a UUID
@@ -176,13 +259,47 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusNotFound, err.Error(), err)
 		return
 	}
+	if chirp.IsDeleted {
+		respondWithError(w, http.StatusNotFound, "Chirp has been deleted", nil)
+		return
+	}
 
 	if chirp.UserID != userID {
 		respondWithError(w, http.StatusForbidden, "Chirp can only be deleted by the author", fmt.Errorf("Chirp can only be deleted by the author"))
 		return
 	}
 
-	err = cfg.db.DeleteChirp(r.Context(), chirpId)
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Chirps with replies are tombstoned rather than deleted so the rest of
+	// the conversation keeps its shape.
+	hasReplies, err := qtx.ChirpHasReplies(r.Context(), chirpId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	if hasReplies {
+		// Rechirps of a hard-deleted chirp go away through ON DELETE
+		// CASCADE; a tombstone has to remove them itself.
+		err = qtx.DeleteRechirpsOf(r.Context(), chirpId)
+		if err == nil {
+			err = qtx.TombstoneChirp(r.Context(), chirpId)
+		}
+	} else {
+		err = qtx.DeleteChirp(r.Context(), chirpId)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
 		return
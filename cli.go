@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+	"github.com/pderyuga/chirpy-go/internal/mailer"
+)
+
+// runCommand handles administrative commands given on the command line, such
+// as granting the first admin before anyone can use the admin endpoints:
+//
+//	go run . grant-role admin@example.com admin
+func runCommand(ctx context.Context, db *database.Queries, args []string) error {
+	switch args[0] {
+	case "grant-role":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: grant-role <email> <role>")
+		}
+		// Emails are stored normalized, so look them up the same way.
+		email, err := mailer.NormalizeAddress(args[1])
+		if err != nil {
+			return err
+		}
+		role := args[2]
+		if !auth.ValidRole(role) {
+			return fmt.Errorf("unknown role %q", role)
+		}
+
+		updated, err := db.SetUserRoleByEmail(ctx, database.SetUserRoleByEmailParams{
+			Email: email,
+			Role:  role,
+		})
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return fmt.Errorf("no user with email %s", email)
+		}
+		fmt.Printf("Granted %s the %s role\n", email, role)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+	"github.com/pderyuga/chirpy-go/internal/mailer"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+// sendVerificationEmail emails userID a link proving they own email. Only
+// a hash of the token is stored.
+func (cfg *apiConfig) sendVerificationEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	token, err := auth.MakeOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	err = cfg.db.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: time.Now().UTC().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := cfg.baseURL + "/api/users/verify?token=" + url.QueryEscape(token)
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Verify your Chirpy email address",
+		Body:    fmt.Sprintf("Welcome to Chirpy! Confirm your email address to start chirping:\n\n%s\n\nThis link expires in 24 hours.", link),
+	})
+}
+
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing verification token", nil)
+		return
+	}
+
+	// Tokens are deleted as they're used, so each link works once.
+	verification, err := cfg.db.ConsumeEmailVerificationToken(r.Context(), auth.HashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Invalid or already used verification token", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't verify email", err)
+		return
+	}
+	if verification.ExpiresAt.Before(time.Now()) {
+		respondWithError(w, http.StatusGone, "Verification token has expired", nil)
+		return
+	}
+
+	// The token is tied to the address it was sent to, in case the user
+	// changed their email since.
+	verified, err := cfg.db.MarkEmailVerified(r.Context(), database.MarkEmailVerifiedParams{
+		ID:    verification.UserID,
+		Email: verification.Email,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't verify email", err)
+		return
+	}
+	if verified == 0 {
+		respondWithError(w, http.StatusConflict, "Email address has changed since this link was sent", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't find user", err)
+		return
+	}
+	if user.IsEmailVerified {
+		respondWithError(w, http.StatusConflict, "Email is already verified", nil)
+		return
+	}
+
+	err = cfg.db.DeleteEmailVerificationTokensForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send verification email", err)
+		return
+	}
+	err = cfg.sendVerificationEmail(r.Context(), userID, user.Email)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send verification email", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireVerifiedEmail responds with 403 and returns false if the caller
+// hasn't verified their email address yet.
+func requireVerifiedEmail(w http.ResponseWriter, r *http.Request) bool {
+	if !auth.PrincipalFromContext(r.Context()).EmailVerified {
+		respondWithError(w, http.StatusForbidden, "Verify your email address before posting", nil)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// breachedPrefixLength is how many hex characters of a SHA-1 hash pick its
+// bucket, as in the Pwned Passwords range API.
+const breachedPrefixLength = 5
+
+// BreachedPasswords is a corpus of SHA-1 hashes of breached passwords,
+// bucketed by hash prefix the way the Pwned Passwords range API serves
+// them. Keeping the same k-anonymity layout means a lookup only ever needs
+// one bucket, so the corpus could be served from elsewhere without sending
+// the hash itself.
+type BreachedPasswords struct {
+	buckets map[string][]string
+}
+
+// LoadBreachedPasswords reads a corpus file. Each line holds a hex SHA-1
+// hash, optionally followed by ":count" as in Pwned Passwords downloads.
+// Blank lines and lines starting with # are skipped.
+func LoadBreachedPasswords(path string) (*BreachedPasswords, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseBreachedPasswords(f)
+}
+
+func ParseBreachedPasswords(r io.Reader) (*BreachedPasswords, error) {
+	b := &BreachedPasswords{buckets: map[string][]string{}}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(text, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("line %d: invalid SHA-1 hash %q", line, hash)
+		}
+		prefix, suffix := hash[:breachedPrefixLength], hash[breachedPrefixLength:]
+		b.buckets[prefix] = append(b.buckets[prefix], suffix)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, bucket := range b.buckets {
+		slices.Sort(bucket)
+	}
+	return b, nil
+}
+
+// Contains reports whether password is in the corpus.
+func (b *BreachedPasswords) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	_, found := slices.BinarySearch(b.buckets[hash[:breachedPrefixLength]], hash[breachedPrefixLength:])
+	return found
+}
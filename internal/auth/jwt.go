@@ -2,27 +2,75 @@ package auth
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	signingKey := []byte(tokenSecret)
+const (
+	ScopeChirpsWrite = "chirps:write"
+	ScopeUsersRead   = "users:read"
+	ScopeUsersWrite  = "users:write"
+)
+
+// AllScopes is what a user gets by logging in with their password.
+var AllScopes = []string{ScopeChirpsWrite, ScopeUsersRead, ScopeUsersWrite}
+
+// TokenConfig is everything needed to issue and check access tokens.
+type TokenConfig struct {
+	Keys *KeySet
+	// Audience is the aud claim tokens are issued for and must carry.
+	Audience string
+	// Leeway is how much clock skew to tolerate when checking exp, nbf and
+	// iat.
+	Leeway time.Duration
+}
+
+// Claims is what an access token says about its holder.
+type Claims struct {
+	UserID uuid.UUID
+	// TokenVersion must match the user's current token_version. Bumping it
+	// invalidates every access token issued before.
+	TokenVersion int32
+	Scopes       []string
+	// TokenID is the jti claim, unique to every token so a single token can
+	// be revoked. MakeJWT generates it.
+	TokenID   string
+	ExpiresAt time.Time
+}
+
+type accessTokenClaims struct {
+	jwt.RegisteredClaims
+	TokenVersion int32  `json:"ver"`
+	Scope        string `json:"scope"`
+}
+
+func MakeJWT(claims Claims, config TokenConfig, expiresIn time.Duration) (string, error) {
 	issuedTime := time.Now().UTC()
 	expirationTime := issuedTime.Add(expiresIn)
 
-	claims := jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(expirationTime),
-		IssuedAt:  jwt.NewNumericDate(issuedTime),
-		Issuer:    "chirpy-access",
-		Subject:   userID.String(),
+	tokenClaims := accessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(issuedTime),
+			Issuer:    "chirpy-access",
+			Subject:   claims.UserID.String(),
+			Audience:  jwt.ClaimStrings{config.Audience},
+			ID:        uuid.NewString(),
+		},
+		TokenVersion: claims.TokenVersion,
+		Scope:        strings.Join(claims.Scopes, " "),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	keys := config.Keys
+	token := jwt.NewWithClaims(keys.signing.Method, tokenClaims)
+	if keys.signing.ID != "" {
+		token.Header["kid"] = keys.signing.ID
+	}
 
-	tokenString, err := token.SignedString(signingKey)
+	tokenString, err := token.SignedString(keys.signing.signingKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -30,43 +78,42 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 	return tokenString, nil
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claims := jwt.RegisteredClaims{}
+func ValidateJWT(tokenString string, config TokenConfig) (Claims, error) {
+	claims := accessTokenClaims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (any, error) {
-		if token.Method != jwt.SigningMethodHS256 {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(tokenSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &claims, config.Keys.keyFunc,
+		jwt.WithIssuer("chirpy-access"),
+		jwt.WithAudience(config.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(config.Leeway),
+	)
 
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to parse JWT: %w", err)
+		return Claims{}, fmt.Errorf("failed to parse JWT: %w", err)
 	}
 
 	// Check if the token is valid after parsing
 	if !token.Valid {
-		return uuid.Nil, fmt.Errorf("invalid token")
-	}
-
-	issuer, err := token.Claims.GetIssuer()
-	if err != nil {
-		return uuid.Nil, err
-	}
-	if issuer != "chirpy-access" {
-		return uuid.Nil, fmt.Errorf("invalid issuer")
+		return Claims{}, fmt.Errorf("invalid token")
 	}
 
 	userIdString, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed get user ID from token: %w", err)
+		return Claims{}, fmt.Errorf("failed get user ID from token: %w", err)
 	}
 
 	id, err := uuid.Parse(userIdString)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to parse user ID from token: %w", err)
+		return Claims{}, fmt.Errorf("failed to parse user ID from token: %w", err)
 	}
 
-	return id, nil
+	return Claims{
+		UserID:       id,
+		TokenVersion: claims.TokenVersion,
+		Scopes:       strings.Fields(claims.Scope),
+		TokenID:      claims.ID,
+		ExpiresAt:    claims.ExpiresAt.Time,
+	}, nil
 
 }
@@ -1,56 +1,134 @@
 package auth
 
 import (
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+func testTokenConfig(secret string) TokenConfig {
+	return TokenConfig{
+		Keys:     NewHMACKeySet(secret),
+		Audience: "chirpy-api",
+	}
+}
+
 func TestValidateJWT(t *testing.T) {
 	userID := uuid.New()
-	validToken, _ := MakeJWT(userID, "secret", time.Hour)
+	config := testTokenConfig("secret")
+	claims := Claims{
+		UserID:       userID,
+		TokenVersion: 3,
+		Scopes:       []string{ScopeChirpsWrite, ScopeUsersRead},
+	}
+
+	validToken, _ := MakeJWT(claims, config, time.Hour)
+	expiredToken, _ := MakeJWT(claims, config, -time.Minute)
+	otherAudienceToken, _ := MakeJWT(claims, TokenConfig{Keys: config.Keys, Audience: "other-api"}, time.Hour)
+
+	otherIssuerToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, accessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "someone-else",
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{"chirpy-api"},
+		},
+	}).SignedString([]byte("secret"))
+
+	lenientConfig := config
+	lenientConfig.Leeway = 2 * time.Minute
 
 	tests := []struct {
 		name        string
 		tokenString string
-		tokenSecret string
-		wantUserID  uuid.UUID
+		config      TokenConfig
+		wantClaims  Claims
 		wantErr     bool
 	}{
 		{
 			name:        "Valid token",
 			tokenString: validToken,
-			tokenSecret: "secret",
-			wantUserID:  userID,
+			config:      config,
+			wantClaims:  claims,
 			wantErr:     false,
 		},
 		{
 			name:        "Invalid token",
 			tokenString: "invalid.token.string",
-			tokenSecret: "secret",
-			wantUserID:  uuid.Nil,
+			config:      config,
+			wantClaims:  Claims{},
 			wantErr:     true,
 		},
 		{
 			name:        "Wrong secret",
 			tokenString: validToken,
-			tokenSecret: "wrong_secret",
-			wantUserID:  uuid.Nil,
+			config:      testTokenConfig("wrong_secret"),
+			wantClaims:  Claims{},
+			wantErr:     true,
+		},
+		{
+			name:        "Expired token",
+			tokenString: expiredToken,
+			config:      config,
+			wantClaims:  Claims{},
+			wantErr:     true,
+		},
+		{
+			name:        "Expired token within leeway",
+			tokenString: expiredToken,
+			config:      lenientConfig,
+			wantClaims:  claims,
+			wantErr:     false,
+		},
+		{
+			name:        "Wrong audience",
+			tokenString: otherAudienceToken,
+			config:      config,
+			wantClaims:  Claims{},
+			wantErr:     true,
+		},
+		{
+			name:        "Wrong issuer",
+			tokenString: otherIssuerToken,
+			config:      config,
+			wantClaims:  Claims{},
 			wantErr:     true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUserID, err := ValidateJWT(tt.tokenString, tt.tokenSecret)
+			gotClaims, err := ValidateJWT(tt.tokenString, tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if gotUserID != tt.wantUserID {
-				t.Errorf("ValidateJWT() gotUserID = %v, want %v", gotUserID, tt.wantUserID)
+			if gotClaims.UserID != tt.wantClaims.UserID ||
+				gotClaims.TokenVersion != tt.wantClaims.TokenVersion ||
+				!slices.Equal(gotClaims.Scopes, tt.wantClaims.Scopes) {
+				t.Errorf("ValidateJWT() gotClaims = %v, want %v", gotClaims, tt.wantClaims)
+			}
+			if !tt.wantErr && (gotClaims.TokenID == "" || gotClaims.ExpiresAt.IsZero()) {
+				t.Errorf("ValidateJWT() should return a token ID and expiry, got %v", gotClaims)
 			}
 		})
 	}
 }
+
+func TestMakeJWTTokenIDsAreUnique(t *testing.T) {
+	config := testTokenConfig("secret")
+	claims := Claims{UserID: uuid.New()}
+
+	first, _ := MakeJWT(claims, config, time.Hour)
+	second, _ := MakeJWT(claims, config, time.Hour)
+
+	firstClaims, _ := ValidateJWT(first, config)
+	secondClaims, _ := ValidateJWT(second, config)
+	if firstClaims.TokenID == secondClaims.TokenID {
+		t.Errorf("MakeJWT() issued the same token ID twice: %v", firstClaims.TokenID)
+	}
+}
@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key signs or verifies access tokens. Asymmetric keys are identified by the
+// kid header of the tokens they sign; a key loaded from a public key file
+// can only verify.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	signingKey any
+	verifyKey  any
+}
+
+// KeySet holds the key new tokens are signed with and every key tokens are
+// still accepted from. Keeping the previous key in the set while a new one
+// takes over lets tokens signed before a rotation stay valid until they
+// expire.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+func NewKeySet(signing *Key, verify ...*Key) (*KeySet, error) {
+	if signing.signingKey == nil {
+		return nil, fmt.Errorf("key %s can't sign tokens", signing.ID)
+	}
+
+	keys := map[string]*Key{signing.ID: signing}
+	for _, key := range verify {
+		if _, ok := keys[key.ID]; ok {
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	return &KeySet{
+		signing: signing,
+		keys:    keys,
+	}, nil
+}
+
+// NewHMACKeySet signs and verifies with a shared HS256 secret. Only services
+// that know the secret can verify these tokens, and nothing is published in
+// the JWKS.
+func NewHMACKeySet(secret string) *KeySet {
+	key := &Key{
+		Method:     jwt.SigningMethodHS256,
+		signingKey: []byte(secret),
+		verifyKey:  []byte(secret),
+	}
+	return &KeySet{
+		signing: key,
+		keys:    map[string]*Key{key.ID: key},
+	}
+}
+
+// LoadKeySet signs with the private key in signingKeyPath and also accepts
+// tokens from the keys in verifyKeyPaths, which may hold public or private
+// keys.
+func LoadKeySet(signingKeyPath string, verifyKeyPaths []string) (*KeySet, error) {
+	signing, err := loadKey(signingKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	verify := make([]*Key, 0, len(verifyKeyPaths))
+	for _, path := range verifyKeyPaths {
+		key, err := loadKey(path)
+		if err != nil {
+			return nil, err
+		}
+		verify = append(verify, key)
+	}
+
+	return NewKeySet(signing, verify...)
+}
+
+func loadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParseKeyPEM parses an RSA or Ed25519 key. Private keys may be PKCS #1
+// (RSA only) or PKCS #8; public keys must be PKIX.
+func ParseKeyPEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.signingKey = k
+		key.verifyKey = &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+		key.verifyKey = k
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.signingKey = k
+		key.verifyKey = k.Public()
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.verifyKey = k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	key.ID, err = thumbprint(key.JWK())
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// keyFunc picks the verification key for a token by its kid header, and
+// refuses tokens signed with a different algorithm than that key uses.
+func (ks *KeySet) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the public half of the key. It is empty for HMAC keys, which
+// have no public half.
+func (k *Key) JWK() JWK {
+	jwk := JWK{
+		Kid: k.ID,
+		Use: "sig",
+		Alg: k.Method.Alg(),
+	}
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return JWK{}
+	}
+	return jwk
+}
+
+// JWKS returns the public keys other services need to verify our tokens.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		jwk := key.JWK()
+		if jwk.Kty != "" {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+	return jwks
+}
+
+// thumbprint derives a key ID from the key itself (RFC 7638), so keys don't
+// need to be named in configuration and every service computes the same ID.
+func thumbprint(jwk JWK) (string, error) {
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
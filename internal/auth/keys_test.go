@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func generateKeyPEMs(t *testing.T) (rsaPEM, ed25519PEM, ed25519PublicPEM []byte) {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519PEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	der, err = x509.MarshalPKIXPublicKey(edPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519PublicPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	return rsaPEM, ed25519PEM, ed25519PublicPEM
+}
+
+func TestKeySetRotation(t *testing.T) {
+	rsaPEM, ed25519PEM, ed25519PublicPEM := generateKeyPEMs(t)
+
+	oldKey, err := ParseKeyPEM(rsaPEM)
+	if err != nil {
+		t.Fatalf("ParseKeyPEM() RSA error = %v", err)
+	}
+	newKey, err := ParseKeyPEM(ed25519PEM)
+	if err != nil {
+		t.Fatalf("ParseKeyPEM() Ed25519 error = %v", err)
+	}
+	newPublicKey, err := ParseKeyPEM(ed25519PublicPEM)
+	if err != nil {
+		t.Fatalf("ParseKeyPEM() Ed25519 public error = %v", err)
+	}
+	if newKey.ID != newPublicKey.ID {
+		t.Errorf("private and public key IDs differ: %v, %v", newKey.ID, newPublicKey.ID)
+	}
+
+	before, _ := NewKeySet(oldKey)
+	during, err := NewKeySet(newKey, oldKey)
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	after, _ := NewKeySet(newKey)
+	verifier := &KeySet{keys: map[string]*Key{newPublicKey.ID: newPublicKey}}
+
+	if _, err := NewKeySet(newPublicKey); err == nil {
+		t.Errorf("NewKeySet() with a public signing key should fail")
+	}
+
+	userID := uuid.New()
+	claims := Claims{UserID: userID}
+	oldToken, _ := MakeJWT(claims, TokenConfig{Keys: before, Audience: "chirpy-api"}, time.Hour)
+	newToken, _ := MakeJWT(claims, TokenConfig{Keys: during, Audience: "chirpy-api"}, time.Hour)
+
+	tests := []struct {
+		name    string
+		token   string
+		keys    *KeySet
+		wantErr bool
+	}{
+		{"Old token during rotation", oldToken, during, false},
+		{"New token during rotation", newToken, during, false},
+		{"Old token after rotation", oldToken, after, true},
+		{"New token with public key only", newToken, verifier, false},
+		{"Asymmetric token with HMAC keys", newToken, NewHMACKeySet("secret"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ValidateJWT(tt.token, TokenConfig{Keys: tt.keys, Audience: "chirpy-api"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && claims.UserID != userID {
+				t.Errorf("ValidateJWT() UserID = %v, want %v", claims.UserID, userID)
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaPEM, ed25519PEM, _ := generateKeyPEMs(t)
+	rsaKey, _ := ParseKeyPEM(rsaPEM)
+	edKey, _ := ParseKeyPEM(ed25519PEM)
+	keys, _ := NewKeySet(edKey, rsaKey)
+
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() returned %d keys, want 2", len(jwks.Keys))
+	}
+	for _, jwk := range jwks.Keys {
+		switch jwk.Kid {
+		case rsaKey.ID:
+			if jwk.Kty != "RSA" || jwk.Alg != "RS256" || jwk.N == "" || jwk.E != "AQAB" {
+				t.Errorf("RSA JWK = %+v", jwk)
+			}
+		case edKey.ID:
+			if jwk.Kty != "OKP" || jwk.Alg != "EdDSA" || jwk.Crv != "Ed25519" || jwk.X == "" {
+				t.Errorf("Ed25519 JWK = %+v", jwk)
+			}
+		default:
+			t.Errorf("unexpected key ID %v", jwk.Kid)
+		}
+	}
+
+	if got := NewHMACKeySet("secret").JWKS(); len(got.Keys) != 0 {
+		t.Errorf("HMAC JWKS() = %v, want no keys", got)
+	}
+}
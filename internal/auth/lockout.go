@@ -0,0 +1,29 @@
+package auth
+
+import "time"
+
+// LockoutPolicy decides how long to refuse logins after repeated failures.
+// The first Threshold-1 failures are free; after that the lockout doubles
+// with every failure, starting at Base and capped at Max.
+type LockoutPolicy struct {
+	Threshold int32
+	Base      time.Duration
+	Max       time.Duration
+}
+
+// LockoutFor returns how long to lock out after the given number of
+// consecutive failures, or zero if no lockout is due yet.
+func (p LockoutPolicy) LockoutFor(failures int32) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+
+	lockout := p.Base
+	for i := p.Threshold; i < failures; i++ {
+		lockout *= 2
+		if lockout >= p.Max {
+			return p.Max
+		}
+	}
+	return lockout
+}
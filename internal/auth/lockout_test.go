@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutFor(t *testing.T) {
+	policy := LockoutPolicy{
+		Threshold: 5,
+		Base:      30 * time.Second,
+		Max:       time.Hour,
+	}
+
+	tests := []struct {
+		failures int32
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: 4, want: 0},
+		{failures: 5, want: 30 * time.Second},
+		{failures: 6, want: time.Minute},
+		{failures: 8, want: 4 * time.Minute},
+		{failures: 12, want: time.Hour},
+		{failures: 1000, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := policy.LockoutFor(tt.failures); got != tt.want {
+			t.Errorf("LockoutFor(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownAccount is returned by an AccountLookup when the token's subject
+// no longer exists.
+var ErrUnknownAccount = errors.New("unknown account")
+
+// Account is what the middleware needs to know about a user beyond their
+// token. It is loaded on every request so role changes and suspensions apply
+// immediately rather than when the token expires.
+type Account struct {
+	Role          string
+	Suspended     bool
+	TokenVersion  int32
+	EmailVerified bool
+}
+
+type AccountLookup func(ctx context.Context, userID uuid.UUID) (Account, error)
+
+type Authenticator struct {
+	tokens TokenConfig
+	lookup AccountLookup
+}
+
+func NewAuthenticator(tokens TokenConfig, lookup AccountLookup) *Authenticator {
+	return &Authenticator{
+		tokens: tokens,
+		lookup: lookup,
+	}
+}
+
+// Required rejects requests without valid credentials and stores the caller's
+// Principal in the request context.
+func (a *Authenticator) Required(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			respondUnauthorized(w, "", "no authorization header included in request")
+			return
+		}
+		a.authenticate(w, r, next, false)
+	})
+}
+
+// Optional lets anonymous requests through with no Principal, but still
+// rejects requests whose credentials are present and invalid. Suspended users
+// are treated as anonymous, so they can still read what everyone can.
+func (a *Authenticator) Optional(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		a.authenticate(w, r, next, true)
+	})
+}
+
+// RequireRole authenticates the request and only calls next if the caller
+// holds role, directly or through a more privileged one.
+func (a *Authenticator) RequireRole(role string, next http.HandlerFunc) http.Handler {
+	return a.Required(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !PrincipalFromContext(r.Context()).HasRole(role) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="chirpy", error="insufficient_scope", error_description="requires the %s role"`, role))
+			respondError(w, http.StatusForbidden, "This requires the "+role+" role")
+			return
+		}
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// RequireScope only calls next if the caller's token was granted scope. It
+// must run behind Required or RequireRole.
+func RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !PrincipalFromContext(r.Context()).HasScope(scope) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="chirpy", error="insufficient_scope", scope="%s"`, scope))
+			respondError(w, http.StatusForbidden, "This requires the "+scope+" scope")
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+func (a *Authenticator) authenticate(w http.ResponseWriter, r *http.Request, next http.Handler, optional bool) {
+	bearerToken, err := GetBearerToken(r.Header)
+	if err != nil {
+		respondUnauthorized(w, "invalid_request", err.Error())
+		return
+	}
+	claims, err := ValidateJWT(bearerToken, a.tokens)
+	if err != nil {
+		respondUnauthorized(w, "invalid_token", err.Error())
+		return
+	}
+
+	account, err := a.lookup(r.Context(), claims.UserID)
+	if errors.Is(err, ErrUnknownAccount) {
+		respondUnauthorized(w, "invalid_token", "token subject doesn't exist")
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		respondError(w, http.StatusInternalServerError, "Couldn't load account")
+		return
+	}
+	if claims.TokenVersion != account.TokenVersion {
+		respondUnauthorized(w, "invalid_token", "token has been revoked")
+		return
+	}
+	if account.Suspended && optional {
+		next.ServeHTTP(w, r)
+		return
+	}
+	if account.Suspended {
+		respondError(w, http.StatusForbidden, "Account is suspended")
+		return
+	}
+
+	ctx := WithPrincipal(r.Context(), Principal{
+		UserID:  claims.UserID,
+		Roles:   expandRole(account.Role),
+		Scopes:  claims.Scopes,
+		TokenID: claims.TokenID,
+		Method:  MethodBearer,
+
+		EmailVerified: account.EmailVerified,
+	})
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// respondUnauthorized sends a 401 with a WWW-Authenticate challenge as
+// described in RFC 6750. errorCode is left out when no credentials were sent.
+func respondUnauthorized(w http.ResponseWriter, errorCode, description string) {
+	challenge := `Bearer realm="chirpy"`
+	if errorCode != "" {
+		challenge += fmt.Sprintf(`, error="%s", error_description="%s"`, errorCode, strings.ReplaceAll(description, `"`, `'`))
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	respondError(w, http.StatusUnauthorized, description)
+}
+
+// respondError writes the same {"error": ...} body as the rest of the API.
+func respondError(w http.ResponseWriter, code int, msg string) {
+	type errorResponse struct {
+		Error string `json:"error"`
+	}
+	data, err := json.Marshal(errorResponse{Error: msg})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
@@ -1,11 +1,26 @@
 package auth
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/alexedwards/argon2id"
 )
 
+// ErrPasswordNotSet means the account has no password hash, which is the
+// case for accounts created before passwords were stored. Such accounts can
+// only get in by resetting their password.
+var ErrPasswordNotSet = errors.New("password not set")
+
+// unsetPasswordHash is the placeholder hashed_password of those accounts.
+const unsetPasswordHash = "unset"
+
+// PasswordParams are the argon2id parameters new hashes are created with.
+// Set it once at startup, before any password is hashed.
+var PasswordParams = argon2id.DefaultParams
+
 func HashPassword(password string) (string, error) {
-	hash, err := argon2id.CreateHash(password, argon2id.DefaultParams)
+	hash, err := argon2id.CreateHash(password, PasswordParams)
 	if err != nil {
 		return "", err
 	}
@@ -13,11 +28,33 @@ func HashPassword(password string) (string, error) {
 	return hash, nil
 }
 
-func CheckPasswordHash(password, hash string) (bool, error) {
-	match, err := argon2id.ComparePasswordAndHash(password, hash)
+// CheckPasswordHash compares password with hash. needsRehash reports that
+// the password matched but the hash was made with parameters other than
+// PasswordParams, so it should be replaced with a fresh HashPassword.
+func CheckPasswordHash(password, hash string) (match, needsRehash bool, err error) {
+	if hash == unsetPasswordHash {
+		return false, false, ErrPasswordNotSet
+	}
+
+	match, params, err := argon2id.CheckHash(password, hash)
+	if err != nil {
+		return false, false, err
+	}
+
+	return match, match && *params != *PasswordParams, nil
+}
+
+var dummyHash = sync.OnceValue(func() string {
+	hash, err := HashPassword("chirpy-dummy-password")
 	if err != nil {
-		return false, err
+		panic(err)
 	}
+	return hash
+})
 
-	return match, nil
+// CheckDummyPasswordHash does the same work as CheckPasswordHash against a
+// hash no password matches. Call it when there is no user to check against,
+// so that login takes as long for unknown emails as for known ones.
+func CheckDummyPasswordHash(password string) {
+	argon2id.ComparePasswordAndHash(password, dummyHash())
 }
@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy decides which passwords are acceptable. Zero fields turn
+// their rule off.
+type PasswordPolicy struct {
+	MinLength int
+	// MaxLength bounds how much work hashing a password can take.
+	MaxLength int
+	// MinStrength is the lowest EstimatePasswordStrength score allowed.
+	MinStrength int
+	// Breached, if set, rejects passwords known from data breaches.
+	Breached *BreachedPasswords
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:   8,
+	MaxLength:   256,
+	MinStrength: 2,
+}
+
+// PasswordViolation is a rule a password failed.
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Check returns every rule password fails, or nothing if it's acceptable. A
+// password over MaxLength is only reported as that, without running the
+// other checks on it.
+// userInputs are things like the user's email address, which make for a
+// weak password.
+func (p PasswordPolicy) Check(password string, userInputs ...string) []PasswordViolation {
+	violations := []PasswordViolation{}
+
+	length := utf8.RuneCountInString(password)
+	if p.MaxLength > 0 && length > p.MaxLength {
+		// The other checks take time proportional to the length, so
+		// oversized passwords stop here.
+		return append(violations, PasswordViolation{
+			Rule:    "max_length",
+			Message: fmt.Sprintf("Password must be at most %d characters", p.MaxLength),
+		})
+	}
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Rule:    "min_length",
+			Message: fmt.Sprintf("Password must be at least %d characters", p.MinLength),
+		})
+	}
+	if p.MinStrength > 0 && EstimatePasswordStrength(password, userInputs...) < p.MinStrength {
+		violations = append(violations, PasswordViolation{
+			Rule:    "strength",
+			Message: "Password is too easy to guess",
+		})
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		violations = append(violations, PasswordViolation{
+			Rule:    "breached",
+			Message: "Password has appeared in a data breach",
+		})
+	}
+
+	return violations
+}
+
+// commonPasswords are matched anywhere in a password, after undoing common
+// character substitutions. Ordered roughly by popularity.
+var commonPasswords = []string{
+	"password", "123456", "qwerty", "letmein", "welcome", "admin", "login",
+	"monkey", "dragon", "football", "baseball", "iloveyou", "master",
+	"sunshine", "princess", "shadow", "superman", "michael", "trustno1",
+	"starwars", "whatever", "freedom", "hello", "charlie", "summer",
+	"winter", "spring", "autumn", "secret", "chirpy", "asdfgh", "zxcvbn",
+	"qazwsx", "abc123", "pass", "love", "test", "user", "root", "changeme",
+}
+
+var substitutions = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i",
+)
+
+// EstimatePasswordStrength scores password from 0 (trivial to guess) to 4
+// (very hard), in the spirit of zxcvbn. It estimates how many guesses an
+// attacker needs, counting common passwords, the user's own details,
+// years, repeated characters and sequences like "abc" or "321" as cheap to
+// guess.
+func EstimatePasswordStrength(password string, userInputs ...string) int {
+	guesses := estimateGuesses(password, userInputs)
+	switch {
+	case guesses < 3:
+		return 0
+	case guesses < 6:
+		return 1
+	case guesses < 8:
+		return 2
+	case guesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// estimateGuesses returns the log10 of how many guesses password takes.
+func estimateGuesses(password string, userInputs []string) float64 {
+	runes := []rune(password)
+	normalized := []rune(substitutions.Replace(strings.ToLower(password)))
+	if len(normalized) != len(runes) {
+		// Can't happen with single-character substitutions, but the
+		// indexes below depend on it.
+		normalized = []rune(strings.ToLower(password))
+	}
+
+	words := commonPasswords
+	for _, input := range userInputs {
+		for _, part := range strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if len(part) >= 3 {
+				words = append(words, part)
+			}
+		}
+	}
+
+	// A dictionary word costs about as much as picking it from the list,
+	// plus a guess at its capitalization.
+	wordGuesses := math.Log10(float64(len(words))) + 0.5
+
+	poolGuesses := math.Log10(float64(characterPool(runes)))
+	total := 0.0
+	for i := 0; i < len(runes); {
+		if word := longestWordAt(normalized, i, words); word > 0 {
+			total += wordGuesses
+			i += word
+			continue
+		}
+		if isYearAt(runes, i) {
+			total += yearGuesses
+			i += 4
+			continue
+		}
+		switch {
+		case i > 0 && runes[i] == runes[i-1]:
+			// Repeats add next to nothing.
+			total += 0.1
+		case i > 1 && runes[i]-runes[i-1] == runes[i-1]-runes[i-2] && abs(runes[i]-runes[i-1]) == 1:
+			// So do sequences like "abc" and "987".
+			total += 0.1
+		default:
+			total += poolGuesses
+		}
+		i++
+	}
+	return total
+}
+
+// Years from 1900 to 2099 are a common suffix.
+var yearGuesses = math.Log10(200)
+
+func isYearAt(password []rune, i int) bool {
+	if i+4 > len(password) {
+		return false
+	}
+	year := string(password[i : i+4])
+	if !strings.HasPrefix(year, "19") && !strings.HasPrefix(year, "20") {
+		return false
+	}
+	return unicode.IsDigit(password[i+2]) && unicode.IsDigit(password[i+3])
+}
+
+func longestWordAt(password []rune, i int, words []string) int {
+	longest := 0
+	for _, word := range words {
+		n := utf8.RuneCountInString(word)
+		if n > longest && i+n <= len(password) && string(password[i:i+n]) == word {
+			longest = n
+		}
+	}
+	return longest
+}
+
+// characterPool is how many characters an attacker would have to try for
+// each position, based on the kinds of characters password uses.
+func characterPool(password []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < utf8.RuneSelf:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	for _, kind := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if kind.used {
+			pool += kind.size
+		}
+	}
+	return max(pool, 1)
+}
+
+func abs(r rune) rune {
+	if r < 0 {
+		return -r
+	}
+	return r
+}
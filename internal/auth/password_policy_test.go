@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestEstimatePasswordStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		minScore int
+		maxScore int
+	}{
+		{"password", 0, 0},
+		{"aaaaaaaa", 0, 0},
+		{"12345678", 0, 0},
+		{"P@ssw0rd!", 0, 1},
+		{"Summer2024!", 0, 2},
+		{"alicealice1", 0, 1},
+		{"Tr0ub4dor&3", 4, 4},
+		{"correct horse battery staple", 4, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			score := EstimatePasswordStrength(tt.password, "alice@example.com")
+			if score < tt.minScore || score > tt.maxScore {
+				t.Errorf("EstimatePasswordStrength() = %v, want between %v and %v", score, tt.minScore, tt.maxScore)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	sum := sha1.Sum([]byte("breached-but-long-and-random-9xQ"))
+	corpus := "# test corpus\n" + strings.ToUpper(hex.EncodeToString(sum[:])) + ":42\n"
+	breached, err := ParseBreachedPasswords(strings.NewReader(corpus))
+	if err != nil {
+		t.Fatalf("ParseBreachedPasswords() error = %v", err)
+	}
+	policy := DefaultPasswordPolicy
+	policy.Breached = breached
+
+	tests := []struct {
+		name      string
+		password  string
+		wantRules []string
+	}{
+		{"Acceptable", "correct horse battery staple", nil},
+		{"Empty", "", []string{"min_length", "strength"}},
+		{"Too long", strings.Repeat("xQ7#", 65), []string{"max_length"}},
+		{"Too long and weak", strings.Repeat("a", 10000), []string{"max_length"}},
+		{"Weak", "password1", []string{"strength"}},
+		{"Breached", "breached-but-long-and-random-9xQ", []string{"breached"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := policy.Check(tt.password, "alice@example.com")
+			rules := []string{}
+			for _, violation := range violations {
+				rules = append(rules, violation.Rule)
+			}
+			if strings.Join(rules, ",") != strings.Join(tt.wantRules, ",") {
+				t.Errorf("Check() rules = %v, want %v", rules, tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestParseBreachedPasswordsInvalid(t *testing.T) {
+	if _, err := ParseBreachedPasswords(strings.NewReader("not-a-hash:3\n")); err == nil {
+		t.Errorf("ParseBreachedPasswords() with an invalid line should fail")
+	}
+}
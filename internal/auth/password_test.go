@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/alexedwards/argon2id"
 )
 
 func TestCheckPasswordHash(t *testing.T) {
@@ -57,7 +60,7 @@ func TestCheckPasswordHash(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			match, err := CheckPasswordHash(tt.password, tt.hash)
+			match, _, err := CheckPasswordHash(tt.password, tt.hash)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CheckPasswordHash() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -67,3 +70,45 @@ func TestCheckPasswordHash(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckPasswordHashNeedsRehash(t *testing.T) {
+	password := "correctPassword123!"
+	current, _ := HashPassword(password)
+	outdated, _ := argon2id.CreateHash(password, &argon2id.Params{
+		Memory:      16 * 1024,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	})
+
+	tests := []struct {
+		name            string
+		password        string
+		hash            string
+		wantNeedsRehash bool
+	}{
+		{"Current parameters", password, current, false},
+		{"Outdated parameters", password, outdated, true},
+		{"Outdated parameters, wrong password", "wrongPassword", outdated, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, needsRehash, err := CheckPasswordHash(tt.password, tt.hash)
+			if err != nil {
+				t.Fatalf("CheckPasswordHash() error = %v", err)
+			}
+			if needsRehash != tt.wantNeedsRehash {
+				t.Errorf("CheckPasswordHash() needsRehash = %v, want %v", needsRehash, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+func TestCheckPasswordHashNotSet(t *testing.T) {
+	_, _, err := CheckPasswordHash("", "unset")
+	if !errors.Is(err, ErrPasswordNotSet) {
+		t.Errorf("CheckPasswordHash() error = %v, want %v", err, ErrPasswordNotSet)
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// roleHierarchy lists roles from least to most privileged. Each role includes
+// the permissions of the roles before it.
+var roleHierarchy = []string{RoleUser, RoleModerator, RoleAdmin}
+
+func ValidRole(role string) bool {
+	return slices.Contains(roleHierarchy, role)
+}
+
+// expandRole returns role together with every role it includes.
+func expandRole(role string) []string {
+	i := slices.Index(roleHierarchy, role)
+	if i == -1 {
+		return []string{}
+	}
+	return slices.Clone(roleHierarchy[:i+1])
+}
+
+// Method is how a request was authenticated.
+type Method string
+
+const MethodBearer Method = "bearer"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID  uuid.UUID
+	Roles   []string
+	Scopes  []string
+	TokenID string
+	Method  Method
+
+	EmailVerified bool
+}
+
+func (p Principal) Authenticated() bool {
+	return p.Method != ""
+}
+
+func (p Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// Outranks reports whether p has a role above role, such as a moderator
+// over a user.
+func (p Principal) Outranks(role string) bool {
+	i := slices.Index(roleHierarchy, role)
+	if i == -1 || i+1 == len(roleHierarchy) {
+		return false
+	}
+	return p.HasRole(roleHierarchy[i+1])
+}
+
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+type principalContextKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller stored by the authentication
+// middleware. Anonymous requests get the zero Principal, whose UserID is
+// uuid.Nil.
+func PrincipalFromContext(ctx context.Context) Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(Principal)
+	return principal
+}
@@ -0,0 +1,28 @@
+package auth
+
+import "testing"
+
+func TestPrincipalOutranks(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller string
+		target string
+		want   bool
+	}{
+		{"Moderator over user", RoleModerator, RoleUser, true},
+		{"Admin over moderator", RoleAdmin, RoleModerator, true},
+		{"Moderator over moderator", RoleModerator, RoleModerator, false},
+		{"Moderator over admin", RoleModerator, RoleAdmin, false},
+		{"Admin over admin", RoleAdmin, RoleAdmin, false},
+		{"Unknown target role", RoleAdmin, "superuser", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal := Principal{Roles: expandRole(tt.caller)}
+			if got := principal.Outranks(tt.target); got != tt.want {
+				t.Errorf("Outranks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHOTPRFC6238Vectors(t *testing.T) {
+	// Test vectors for SHA-1 from RFC 6238, appendix B.
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		if got := hotp(key, uint64(tt.unix/30), 8); got != tt.want {
+			t.Errorf("hotp() at %d = %v, want %v", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+
+	tests := []struct {
+		name     string
+		code     string
+		at       time.Time
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "Current code", code: "081804", at: now, wantStep: 37037036, wantOK: true},
+		{name: "Previous period", code: "081804", at: now.Add(30 * time.Second), wantStep: 37037036, wantOK: true},
+		{name: "Too old", code: "081804", at: now.Add(90 * time.Second), wantOK: false},
+		{name: "Wrong code", code: "123456", at: now, wantOK: false},
+		{name: "Wrong length", code: "81804", at: now, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(secret, tt.code, tt.at)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP() = %v, %v, want %v, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	got := TOTPProvisioningURI("Chirpy", "walt@breakingbad.com", "JBSWY3DPEHPK3PXP")
+	for _, want := range []string{"otpauth://totp/Chirpy:walt@breakingbad.com?", "secret=JBSWY3DPEHPK3PXP", "issuer=Chirpy", "digits=6", "period=30"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("TOTPProvisioningURI() = %v, want it to contain %v", got, want)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("recovery code %q has the wrong format", code)
+		}
+		if seen[code] {
+			t.Errorf("recovery code %q generated twice", code)
+		}
+		seen[code] = true
+
+		typed := strings.ToUpper(strings.ReplaceAll(code, "-", ""))
+		if NormalizeRecoveryCode(typed) != code {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", typed, NormalizeRecoveryCode(typed), code)
+		}
+	}
+}
@@ -7,25 +7,79 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const chirpHasReplies = `-- name: ChirpHasReplies :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps WHERE in_reply_to = $1::uuid
+)
+`
+
+func (q *Queries) ChirpHasReplies(ctx context.Context, chirpID uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, chirpHasReplies, chirpID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, quote_of)
 VALUES (
-    gen_random_uuid(), NOW(), NOW(), $1, $2
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, is_deleted, rechirp_of, quote_of, is_hidden
 `
 
 type CreateChirpParams struct {
-	Body   string    `json:"body"`
-	UserID uuid.UUID `json:"user_id"`
+	Body      string        `json:"body"`
+	UserID    uuid.UUID     `json:"user_id"`
+	InReplyTo uuid.NullUUID `json:"in_reply_to"`
+	QuoteOf   uuid.NullUUID `json:"quote_of"`
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.QuoteOf,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.IsDeleted,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.IsHidden,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(), '', $1, $2
+)
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL DO NOTHING
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, is_deleted, rechirp_of, quote_of, is_hidden
+`
+
+type CreateRechirpParams struct {
+	UserID    uuid.UUID     `json:"user_id"`
+	RechirpOf uuid.NullUUID `json:"rechirp_of"`
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp, arg.UserID, arg.RechirpOf)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -33,6 +87,11 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.IsDeleted,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.IsHidden,
 	)
 	return i, err
 }
@@ -46,8 +105,80 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteRechirp = `-- name: DeleteRechirp :exec
+DELETE FROM chirps
+WHERE user_id = $1 AND rechirp_of = $2
+`
+
+type DeleteRechirpParams struct {
+	UserID    uuid.UUID     `json:"user_id"`
+	RechirpOf uuid.NullUUID `json:"rechirp_of"`
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirp, arg.UserID, arg.RechirpOf)
+	return err
+}
+
+const deleteRechirpsOf = `-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of = $1::uuid
+`
+
+func (q *Queries) DeleteRechirpsOf(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirpsOf, chirpID)
+	return err
+}
+
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.in_reply_to AS id FROM chirps parent
+    WHERE parent.id = $1
+    UNION
+    SELECT c.in_reply_to FROM chirps c
+    JOIN ancestors a ON c.id = a.id
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.is_deleted, chirps.rechirp_of, chirps.quote_of, chirps.is_hidden FROM chirps
+WHERE chirps.id IN (SELECT id FROM ancestors)
+ORDER BY chirps.created_at ASC, chirps.id ASC
+`
+
+func (q *Queries) GetChirpAncestors(ctx context.Context, id uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.IsDeleted,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.IsHidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpById = `-- name: GetChirpById :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, is_deleted, rechirp_of, quote_of, is_hidden FROM chirps
 WHERE id = $1
 `
 
@@ -60,17 +191,47 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.IsDeleted,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.IsHidden,
 	)
 	return i, err
 }
 
-const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at ASC
+const getChirpDescendantsPage = `-- name: GetChirpDescendantsPage :many
+WITH RECURSIVE descendants AS (
+    SELECT c.id FROM chirps c
+    WHERE c.in_reply_to = $1
+    UNION
+    SELECT c.id FROM chirps c
+    JOIN descendants d ON c.in_reply_to = d.id
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.is_deleted, chirps.rechirp_of, chirps.quote_of, chirps.is_hidden FROM chirps
+WHERE chirps.id IN (SELECT id FROM descendants)
+AND (
+    $2::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) > ($2::timestamp, $3::uuid)
+)
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT $4
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+type GetChirpDescendantsPageParams struct {
+	ChirpID         uuid.UUID     `json:"chirp_id"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	PageSize        int32         `json:"page_size"`
+}
+
+func (q *Queries) GetChirpDescendantsPage(ctx context.Context, arg GetChirpDescendantsPageParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendantsPage,
+		arg.ChirpID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -84,6 +245,11 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.IsDeleted,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.IsHidden,
 		); err != nil {
 			return nil, err
 		}
@@ -98,14 +264,13 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getChirpsForAuthorId = `-- name: GetChirpsForAuthorId :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC
+const getChirpsByIds = `-- name: GetChirpsByIds :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, is_deleted, rechirp_of, quote_of, is_hidden FROM chirps
+WHERE id = ANY($1::uuid[])
 `
 
-func (q *Queries) GetChirpsForAuthorId(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsForAuthorId, userID)
+func (q *Queries) GetChirpsByIds(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIds, pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +284,11 @@ func (q *Queries) GetChirpsForAuthorId(ctx context.Context, userID uuid.UUID) ([
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.IsDeleted,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.IsHidden,
 		); err != nil {
 			return nil, err
 		}
@@ -132,3 +302,176 @@ func (q *Queries) GetChirpsForAuthorId(ctx context.Context, userID uuid.UUID) ([
 	}
 	return items, nil
 }
+
+const getChirpsPageAsc = `-- name: GetChirpsPageAsc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, is_deleted, rechirp_of, quote_of, is_hidden FROM chirps
+WHERE NOT is_deleted
+AND NOT is_hidden
+AND ($1::uuid IS NULL OR user_id = $1)
+AND (
+    $2::timestamp IS NULL
+    OR (created_at, id) > ($2::timestamp, $3::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type GetChirpsPageAscParams struct {
+	AuthorID        uuid.NullUUID `json:"author_id"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	PageSize        int32         `json:"page_size"`
+}
+
+func (q *Queries) GetChirpsPageAsc(ctx context.Context, arg GetChirpsPageAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPageAsc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.IsDeleted,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.IsHidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsPageDesc = `-- name: GetChirpsPageDesc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, is_deleted, rechirp_of, quote_of, is_hidden FROM chirps
+WHERE NOT is_deleted
+AND NOT is_hidden
+AND ($1::uuid IS NULL OR user_id = $1)
+AND (
+    $2::timestamp IS NULL
+    OR (created_at, id) < ($2::timestamp, $3::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type GetChirpsPageDescParams struct {
+	AuthorID        uuid.NullUUID `json:"author_id"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	PageSize        int32         `json:"page_size"`
+}
+
+func (q *Queries) GetChirpsPageDesc(ctx context.Context, arg GetChirpsPageDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPageDesc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.IsDeleted,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.IsHidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRechirp = `-- name: GetRechirp :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, is_deleted, rechirp_of, quote_of, is_hidden FROM chirps
+WHERE user_id = $1 AND rechirp_of = $2
+`
+
+type GetRechirpParams struct {
+	UserID    uuid.UUID     `json:"user_id"`
+	RechirpOf uuid.NullUUID `json:"rechirp_of"`
+}
+
+func (q *Queries) GetRechirp(ctx context.Context, arg GetRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getRechirp, arg.UserID, arg.RechirpOf)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.IsDeleted,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.IsHidden,
+	)
+	return i, err
+}
+
+const setChirpHidden = `-- name: SetChirpHidden :exec
+UPDATE chirps
+SET is_hidden = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetChirpHiddenParams struct {
+	ID       uuid.UUID `json:"id"`
+	IsHidden bool      `json:"is_hidden"`
+}
+
+func (q *Queries) SetChirpHidden(ctx context.Context, arg SetChirpHiddenParams) error {
+	_, err := q.db.ExecContext(ctx, setChirpHidden, arg.ID, arg.IsHidden)
+	return err
+}
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '', is_deleted = TRUE, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
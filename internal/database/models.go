@@ -11,28 +11,126 @@ import (
 	"github.com/google/uuid"
 )
 
+type AuditLog struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Event     string        `json:"event"`
+	UserID    uuid.NullUUID `json:"user_id"`
+	IpAddress string        `json:"ip_address"`
+	Detail    string        `json:"detail"`
+}
+
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Body      string        `json:"body"`
+	UserID    uuid.UUID     `json:"user_id"`
+	InReplyTo uuid.NullUUID `json:"in_reply_to"`
+	IsDeleted bool          `json:"is_deleted"`
+	RechirpOf uuid.NullUUID `json:"rechirp_of"`
+	QuoteOf   uuid.NullUUID `json:"quote_of"`
+	IsHidden  bool          `json:"is_hidden"`
+}
+
+type ChirpHashtag struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+	Tag     string    `json:"tag"`
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
+}
+
+type ChirpMention struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+	UserID  uuid.UUID `json:"user_id"`
+}
+
+type EmailVerificationToken struct {
+	TokenHash string    `json:"token_hash"`
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type Follow struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	FolloweeID uuid.UUID `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type LoginFailure struct {
+	Key           string       `json:"key"`
+	Failures      int32        `json:"failures"`
+	LastFailureAt time.Time    `json:"last_failure_at"`
+	LockedUntil   sql.NullTime `json:"locked_until"`
+}
+
+type MfaRecoveryCode struct {
 	UserID    uuid.UUID `json:"user_id"`
+	CodeHash  string    `json:"code_hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	TokenHash string    `json:"token_hash"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type RateLimitBucket struct {
+	Key       string    `json:"key"`
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type RefreshToken struct {
-	Token     string       `json:"token"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
-	UserID    uuid.UUID    `json:"user_id"`
-	ExpiresAt time.Time    `json:"expires_at"`
-	RevokedAt sql.NullTime `json:"revoked_at"`
+	Token      string         `json:"token"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	UserID     uuid.UUID      `json:"user_id"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+	RevokedAt  sql.NullTime   `json:"revoked_at"`
+	FamilyID   uuid.UUID      `json:"family_id"`
+	ReplacedBy sql.NullString `json:"replaced_by"`
+	UserAgent  string         `json:"user_agent"`
+	IpAddress  string         `json:"ip_address"`
+	LastUsedAt time.Time      `json:"last_used_at"`
+}
+
+type Report struct {
+	ID         uuid.UUID     `json:"id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	ChirpID    uuid.UUID     `json:"chirp_id"`
+	ReporterID uuid.NullUUID `json:"reporter_id"`
+	Reason     string        `json:"reason"`
+	Status     string        `json:"status"`
+	ResolvedBy uuid.NullUUID `json:"resolved_by"`
+	ResolvedAt sql.NullTime  `json:"resolved_at"`
 }
 
 type User struct {
-	ID             uuid.UUID `json:"id"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	Email          string    `json:"email"`
-	HashedPassword string    `json:"hashed_password"`
-	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	ID              uuid.UUID      `json:"id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	Email           string         `json:"email"`
+	HashedPassword  string         `json:"hashed_password"`
+	IsChirpyRed     bool           `json:"is_chirpy_red"`
+	IsSuspended     bool           `json:"is_suspended"`
+	Role            string         `json:"role"`
+	TokenVersion    int32          `json:"token_version"`
+	IsEmailVerified bool           `json:"is_email_verified"`
+	TotpSecret      sql.NullString `json:"totp_secret"`
+	TotpEnabled     bool           `json:"totp_enabled"`
+	TotpLastStep    int64          `json:"totp_last_step"`
+	Handle          string         `json:"handle"`
+	DisplayName     string         `json:"display_name"`
+	Bio             string         `json:"bio"`
+	AvatarUrl       string         `json:"avatar_url"`
 }
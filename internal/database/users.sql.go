@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,7 +18,8 @@ INSERT INTO users (id, created_at, updated_at, email, hashed_password)
 VALUES (
     gen_random_uuid(), NOW(), NOW(), $1, $2
 )
-RETURNING id, created_at, updated_at, email, is_chirpy_red
+RETURNING id, created_at, updated_at, email, is_chirpy_red, is_email_verified,
+    handle, display_name, bio, avatar_url
 `
 
 type CreateUserParams struct {
@@ -26,11 +28,16 @@ type CreateUserParams struct {
 }
 
 type CreateUserRow struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Email       string    `json:"email"`
-	IsChirpyRed bool      `json:"is_chirpy_red"`
+	ID              uuid.UUID `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Email           string    `json:"email"`
+	IsChirpyRed     bool      `json:"is_chirpy_red"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	Handle          string    `json:"handle"`
+	DisplayName     string    `json:"display_name"`
+	Bio             string    `json:"bio"`
+	AvatarUrl       string    `json:"avatar_url"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
@@ -42,15 +49,22 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateU
 		&i.UpdatedAt,
 		&i.Email,
 		&i.IsChirpyRed,
+		&i.IsEmailVerified,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
 	)
 	return i, err
 }
 
 const editUser = `-- name: EditUser :one
 UPDATE users
-SET email = $2, hashed_password = $3, updated_at = NOW()
+SET email = $2, hashed_password = $3, updated_at = NOW(),
+    is_email_verified = is_email_verified AND email = $2
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, is_chirpy_red
+RETURNING id, created_at, updated_at, email, is_chirpy_red, is_email_verified,
+    handle, display_name, bio, avatar_url
 `
 
 type EditUserParams struct {
@@ -60,11 +74,16 @@ type EditUserParams struct {
 }
 
 type EditUserRow struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Email       string    `json:"email"`
-	IsChirpyRed bool      `json:"is_chirpy_red"`
+	ID              uuid.UUID `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Email           string    `json:"email"`
+	IsChirpyRed     bool      `json:"is_chirpy_red"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	Handle          string    `json:"handle"`
+	DisplayName     string    `json:"display_name"`
+	Bio             string    `json:"bio"`
+	AvatarUrl       string    `json:"avatar_url"`
 }
 
 func (q *Queries) EditUser(ctx context.Context, arg EditUserParams) (EditUserRow, error) {
@@ -76,12 +95,17 @@ func (q *Queries) EditUser(ctx context.Context, arg EditUserParams) (EditUserRow
 		&i.UpdatedAt,
 		&i.Email,
 		&i.IsChirpyRed,
+		&i.IsEmailVerified,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_suspended, role, token_version, is_email_verified, totp_secret, totp_enabled, totp_last_step, handle, display_name, bio, avatar_url FROM users
 WHERE email=$1
 `
 
@@ -95,6 +119,218 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsSuspended,
+		&i.Role,
+		&i.TokenVersion,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastStep,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+	)
+	return i, err
+}
+
+const getUserById = `-- name: GetUserById :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_suspended, role, token_version, is_email_verified, totp_secret, totp_enabled, totp_last_step, handle, display_name, bio, avatar_url FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserById, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsSuspended,
+		&i.Role,
+		&i.TokenVersion,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastStep,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+	)
+	return i, err
+}
+
+const incrementTokenVersion = `-- name: IncrementTokenVersion :one
+UPDATE users
+SET token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING token_version
+`
+
+func (q *Queries) IncrementTokenVersion(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, incrementTokenVersion, id)
+	var tokenVersion int32
+	err := row.Scan(&tokenVersion)
+	return tokenVersion, err
+}
+
+const rehashUserPassword = `-- name: RehashUserPassword :execrows
+UPDATE users
+SET hashed_password = $1, updated_at = NOW()
+WHERE id = $2 AND hashed_password = $3
+`
+
+type RehashUserPasswordParams struct {
+	NewHash string    `json:"new_hash"`
+	ID      uuid.UUID `json:"id"`
+	OldHash string    `json:"old_hash"`
+}
+
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rehashUserPassword, arg.NewHash, arg.ID, arg.OldHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users
+SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserPasswordParams struct {
+	ID             uuid.UUID `json:"id"`
+	HashedPassword string    `json:"hashed_password"`
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.ID, arg.HashedPassword)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :execrows
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID `json:"id"`
+	Role string    `json:"role"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserRole, arg.ID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserRoleByEmail = `-- name: SetUserRoleByEmail :execrows
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE email = $1
+`
+
+type SetUserRoleByEmailParams struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+func (q *Queries) SetUserRoleByEmail(ctx context.Context, arg SetUserRoleByEmailParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserRoleByEmail, arg.Email, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserSuspended = `-- name: SetUserSuspended :execrows
+UPDATE users
+SET is_suspended = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserSuspendedParams struct {
+	ID          uuid.UUID `json:"id"`
+	IsSuspended bool      `json:"is_suspended"`
+}
+
+func (q *Queries) SetUserSuspended(ctx context.Context, arg SetUserSuspendedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserSuspended, arg.ID, arg.IsSuspended)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET email = COALESCE($1, email),
+    hashed_password = COALESCE($2, hashed_password),
+    handle = COALESCE($3, handle),
+    display_name = COALESCE($4, display_name),
+    bio = COALESCE($5, bio),
+    avatar_url = COALESCE($6, avatar_url),
+    is_email_verified = is_email_verified AND email = COALESCE($1, email),
+    updated_at = NOW()
+WHERE id = $7
+RETURNING id, created_at, updated_at, email, is_chirpy_red, is_email_verified,
+    handle, display_name, bio, avatar_url
+`
+
+type UpdateUserProfileParams struct {
+	Email          sql.NullString `json:"email"`
+	HashedPassword sql.NullString `json:"hashed_password"`
+	Handle         sql.NullString `json:"handle"`
+	DisplayName    sql.NullString `json:"display_name"`
+	Bio            sql.NullString `json:"bio"`
+	AvatarUrl      sql.NullString `json:"avatar_url"`
+	ID             uuid.UUID      `json:"id"`
+}
+
+type UpdateUserProfileRow struct {
+	ID              uuid.UUID `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Email           string    `json:"email"`
+	IsChirpyRed     bool      `json:"is_chirpy_red"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	Handle          string    `json:"handle"`
+	DisplayName     string    `json:"display_name"`
+	Bio             string    `json:"bio"`
+	AvatarUrl       string    `json:"avatar_url"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (UpdateUserProfileRow, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarUrl,
+		arg.ID,
+	)
+	var i UpdateUserProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.IsChirpyRed,
+		&i.IsEmailVerified,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
 	)
 	return i, err
 }
@@ -103,15 +339,21 @@ const upgradeUser = `-- name: UpgradeUser :one
 UPDATE users
 SET is_chirpy_red = TRUE, updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, is_chirpy_red
+RETURNING id, created_at, updated_at, email, is_chirpy_red, is_email_verified,
+    handle, display_name, bio, avatar_url
 `
 
 type UpgradeUserRow struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Email       string    `json:"email"`
-	IsChirpyRed bool      `json:"is_chirpy_red"`
+	ID              uuid.UUID `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Email           string    `json:"email"`
+	IsChirpyRed     bool      `json:"is_chirpy_red"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	Handle          string    `json:"handle"`
+	DisplayName     string    `json:"display_name"`
+	Bio             string    `json:"bio"`
+	AvatarUrl       string    `json:"avatar_url"`
 }
 
 func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (UpgradeUserRow, error) {
@@ -123,6 +365,11 @@ func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (UpgradeUserRow
 		&i.UpdatedAt,
 		&i.Email,
 		&i.IsChirpyRed,
+		&i.IsEmailVerified,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
 	)
 	return i, err
 }
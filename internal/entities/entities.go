@@ -0,0 +1,128 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+type Kind string
+
+const (
+	Hashtag Kind = "hashtag"
+	Mention Kind = "mention"
+)
+
+// Entity is a hashtag or mention found in a chirp body. Start and End are
+// offsets in Unicode code points, End exclusive, and cover the leading # or @.
+type Entity struct {
+	Type  Kind   `json:"type"`
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Extract finds #hashtags and @mentions in body. A mention is either a handle
+// (@alice) or an email address (@alice@example.com). Markers in the middle of
+// a word, like the @ in an email address, don't start an entity.
+func Extract(body string) []Entity {
+	runes := []rune(body)
+	found := []Entity{}
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' && runes[i] != '@' {
+			continue
+		}
+		if i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+
+		var end int
+		var kind Kind
+		if runes[i] == '#' {
+			kind = Hashtag
+			end = scan(runes, i+1, isWordRune)
+		} else {
+			kind = Mention
+			end = scan(runes, i+1, isMentionRune)
+			// Sentence punctuation after an email address isn't part of it.
+			for end > i+1 && strings.ContainsRune(".-+%@", runes[end-1]) {
+				end--
+			}
+		}
+
+		text := string(runes[i+1 : end])
+		if !valid(kind, text) {
+			continue
+		}
+
+		found = append(found, Entity{
+			Type:  kind,
+			Text:  text,
+			Start: i,
+			End:   end,
+		})
+		i = end - 1
+	}
+
+	return found
+}
+
+// Hashtags returns the distinct, lowercased tags in entities.
+func Hashtags(entities []Entity) []string {
+	return distinct(entities, Hashtag)
+}
+
+// Mentions returns the distinct, lowercased handles and email addresses in
+// entities.
+func Mentions(entities []Entity) []string {
+	return distinct(entities, Mention)
+}
+
+func distinct(entities []Entity, kind Kind) []string {
+	seen := map[string]struct{}{}
+	values := []string{}
+	for _, entity := range entities {
+		if entity.Type != kind {
+			continue
+		}
+		value := strings.ToLower(entity.Text)
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		values = append(values, value)
+	}
+	return values
+}
+
+func valid(kind Kind, text string) bool {
+	if text == "" {
+		return false
+	}
+	if kind == Hashtag {
+		// #1 is a number, not a tag.
+		return strings.IndexFunc(text, unicode.IsLetter) >= 0
+	}
+
+	local, domain, isEmail := strings.Cut(text, "@")
+	if !isEmail {
+		return strings.IndexFunc(text, func(r rune) bool { return !isWordRune(r) }) < 0
+	}
+	return local != "" && strings.Contains(domain, ".") && !strings.Contains(domain, "@")
+}
+
+func scan(runes []rune, start int, accept func(rune) bool) int {
+	end := start
+	for end < len(runes) && accept(runes[end]) {
+		end++
+	}
+	return end
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isMentionRune(r rune) bool {
+	return isWordRune(r) || strings.ContainsRune(".-+%@", r)
+}
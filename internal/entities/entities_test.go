@@ -0,0 +1,71 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Entity
+	}{
+		{
+			name: "No entities",
+			body: "just a regular chirp",
+			want: []Entity{},
+		},
+		{
+			name: "Hashtag and handle mention",
+			body: "#golang with @alice",
+			want: []Entity{
+				{Type: Hashtag, Text: "golang", Start: 0, End: 7},
+				{Type: Mention, Text: "alice", Start: 13, End: 19},
+			},
+		},
+		{
+			name: "Email mention with trailing punctuation",
+			body: "hi @bob@example.com.",
+			want: []Entity{
+				{Type: Mention, Text: "bob@example.com", Start: 3, End: 19},
+			},
+		},
+		{
+			name: "Email address without a leading @ is not a mention",
+			body: "write to bob@example.com",
+			want: []Entity{},
+		},
+		{
+			name: "Numeric hashtag is ignored",
+			body: "we're #1 at #chirpy2",
+			want: []Entity{
+				{Type: Hashtag, Text: "chirpy2", Start: 12, End: 20},
+			},
+		},
+		{
+			name: "Offsets count code points",
+			body: "héllo #wörld",
+			want: []Entity{
+				{Type: Hashtag, Text: "wörld", Start: 6, End: 12},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Extract(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashtags(t *testing.T) {
+	got := Hashtags(Extract("#Go #go #chirpy @alice"))
+	want := []string{"go", "chirpy"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Hashtags() = %v, want %v", got, want)
+	}
+}
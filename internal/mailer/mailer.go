@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends transactional email such as verification links.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NormalizeAddress checks that address is a bare RFC 5322 address, without a
+// display name, and returns it trimmed and lowercased so the same mailbox
+// can't be registered twice with different casing.
+func NormalizeAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid email address: %w", err)
+	}
+	if parsed.Name != "" || parsed.Address != address {
+		return "", fmt.Errorf("invalid email address: expected a bare address")
+	}
+	if !strings.Contains(parsed.Address[strings.LastIndex(parsed.Address, "@")+1:], ".") {
+		return "", fmt.Errorf("invalid email address: domain must be fully qualified")
+	}
+	return strings.ToLower(parsed.Address), nil
+}
+
+// LogMailer writes messages to w instead of sending them, for development.
+type LogMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogMailer(w io.Writer) *LogMailer {
+	return &LogMailer{w: w}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "--- %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().UTC().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
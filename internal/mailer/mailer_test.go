@@ -0,0 +1,79 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		want    string
+		wantErr bool
+	}{
+		{name: "Plain address", address: "walt@breakingbad.com", want: "walt@breakingbad.com"},
+		{name: "Mixed case and spaces", address: "  Walt@BreakingBad.com ", want: "walt@breakingbad.com"},
+		{name: "Plus addressing", address: "walt+chirpy@breakingbad.com", want: "walt+chirpy@breakingbad.com"},
+		{name: "Missing at sign", address: "walt.breakingbad.com", wantErr: true},
+		{name: "Display name", address: "Walter White <walt@breakingbad.com>", wantErr: true},
+		{name: "Unqualified domain", address: "walt@localhost", wantErr: true},
+		{name: "Empty", address: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeAddress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewLogMailer(&buf)
+
+	err := m.Send(context.Background(), Message{
+		To:      "walt@breakingbad.com",
+		Subject: "Verify your email",
+		Body:    "https://example.com/verify",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	for _, want := range []string{"To: walt@breakingbad.com", "Subject: Verify your email", "https://example.com/verify"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Send() output = %q, want it to contain %q", buf.String(), want)
+		}
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	got := string(buildMessage("chirpy@example.com", Message{
+		To:      "walt@breakingbad.com",
+		Subject: "Verify your email",
+		Body:    "line one\nline two",
+	}, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	want := "From: chirpy@example.com\r\n" +
+		"To: walt@breakingbad.com\r\n" +
+		"Subject: Verify your email\r\n" +
+		"Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"line one\r\nline two"
+	if got != want {
+		t.Errorf("buildMessage() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer sends mail through an SMTP server using STARTTLS when the
+// server offers it.
+type SMTPMailer struct {
+	addr string
+	host string
+	from string
+	// envelopeFrom is the bare address from may wrap, e.g. "Chirpy <a@b.c>".
+	envelopeFrom string
+	auth         smtp.Auth
+}
+
+// NewSMTPMailer authenticates with PLAIN auth if username is set.
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{
+		addr:         net.JoinHostPort(host, port),
+		host:         host,
+		from:         from,
+		envelopeFrom: from,
+	}
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		m.envelopeFrom = parsed.Address
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	// net/smtp has no context support, so the context only stops us from
+	// starting a send that is no longer wanted.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, m.envelopeFrom, []string{msg.To}, buildMessage(m.from, msg, time.Now()))
+}
+
+func buildMessage(from string, msg Message, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
@@ -0,0 +1,102 @@
+package moderation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const placeholder = "****"
+
+// WordList matches whole words after normalization, so "Kerfuffle!",
+// "k3rfuffl3" and "kеrfufflе" (with Cyrillic e) all match "kerfuffle".
+type WordList struct {
+	words  map[string]struct{}
+	action Action
+}
+
+func NewWordList(words []string, action Action) *WordList {
+	list := &WordList{
+		words:  make(map[string]struct{}, len(words)),
+		action: action,
+	}
+	for _, word := range words {
+		normalized := Normalize(word)
+		if normalized != "" {
+			list.words[normalized] = struct{}{}
+		}
+	}
+	return list
+}
+
+func (l *WordList) Check(body string) Result {
+	result := Result{Body: body, Action: Allow}
+
+	words := strings.Split(body, " ")
+	for i, word := range words {
+		prefix, core, suffix := splitPunctuation(word)
+		if _, ok := l.words[Normalize(core)]; !ok {
+			continue
+		}
+
+		result.Action = l.action
+		if l.action == Mask {
+			words[i] = prefix + placeholder + suffix
+			continue
+		}
+		result.Reasons = append(result.Reasons, "contains a blocked word")
+		break
+	}
+
+	if result.Action == Mask {
+		result.Body = strings.Join(words, " ")
+	}
+	return result
+}
+
+// splitPunctuation separates leading and trailing punctuation from a word,
+// keeping symbols that are commonly used as leetspeak letters.
+func splitPunctuation(word string) (prefix, core, suffix string) {
+	isPunctuation := func(r rune) bool {
+		return (unicode.IsPunct(r) || unicode.IsSymbol(r)) && !isLeetspeak(r)
+	}
+	core = strings.TrimLeftFunc(word, isPunctuation)
+	prefix = word[:len(word)-len(core)]
+	trimmed := strings.TrimRightFunc(core, isPunctuation)
+	suffix = core[len(trimmed):]
+	return prefix, trimmed, suffix
+}
+
+// RegexRule applies an action to chirps matching a pattern. Masking replaces
+// each match; flagging and rejecting report the rule's reason.
+type RegexRule struct {
+	pattern *regexp.Regexp
+	action  Action
+	reason  string
+}
+
+func NewRegexRule(pattern *regexp.Regexp, action Action, reason string) *RegexRule {
+	return &RegexRule{
+		pattern: pattern,
+		action:  action,
+		reason:  reason,
+	}
+}
+
+func (r *RegexRule) Check(body string) Result {
+	if !r.pattern.MatchString(body) {
+		return Result{Body: body, Action: Allow}
+	}
+
+	if r.action == Mask {
+		return Result{
+			Body:   r.pattern.ReplaceAllString(body, placeholder),
+			Action: Mask,
+		}
+	}
+	return Result{
+		Body:    body,
+		Action:  r.action,
+		Reasons: []string{r.reason},
+	}
+}
@@ -0,0 +1,76 @@
+package moderation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Action is what a filter decided to do with a chirp. Actions are ordered by
+// severity, so combining results keeps the highest one.
+type Action int
+
+const (
+	Allow Action = iota
+	Mask
+	Flag
+	Reject
+)
+
+func ParseAction(s string) (Action, error) {
+	switch strings.ToLower(s) {
+	case "mask":
+		return Mask, nil
+	case "flag":
+		return Flag, nil
+	case "reject":
+		return Reject, nil
+	}
+	return Allow, fmt.Errorf("unknown moderation action %q", s)
+}
+
+func (a Action) String() string {
+	switch a {
+	case Mask:
+		return "mask"
+	case Flag:
+		return "flag"
+	case Reject:
+		return "reject"
+	}
+	return "allow"
+}
+
+// Result is the outcome of moderating a chirp. Body has any masking applied.
+// Reasons explain why the chirp was flagged or rejected.
+type Result struct {
+	Body    string
+	Action  Action
+	Reasons []string
+}
+
+// Filter inspects a chirp body and decides what to do with it.
+type Filter interface {
+	Check(body string) Result
+}
+
+// Chain runs filters in order, feeding each the body as masked by the ones
+// before it. It stops at the first rejection.
+type Chain []Filter
+
+func (c Chain) Check(body string) Result {
+	result := Result{Body: body, Action: Allow}
+
+	for _, filter := range c {
+		next := filter.Check(result.Body)
+		result.Body = next.Body
+		result.Reasons = append(result.Reasons, next.Reasons...)
+		if next.Action > result.Action {
+			result.Action = next.Action
+		}
+		if result.Action == Reject {
+			break
+		}
+	}
+
+	return result
+}
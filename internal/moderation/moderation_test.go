@@ -0,0 +1,158 @@
+package moderation
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestWordListMask(t *testing.T) {
+	list := NewWordList(DefaultWords, Mask)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "Clean chirp",
+			body: "This is a clean chirp",
+			want: "This is a clean chirp",
+		},
+		{
+			name: "Mixed case",
+			body: "What a Kerfuffle today",
+			want: "What a **** today",
+		},
+		{
+			name: "Trailing punctuation is kept",
+			body: "What a kerfuffle!",
+			want: "What a ****!",
+		},
+		{
+			name: "Leetspeak",
+			body: "sh4rb3rt for dinner",
+			want: "**** for dinner",
+		},
+		{
+			name: "Homoglyphs",
+			body: "fоrnах",
+			want: "****",
+		},
+		{
+			name: "Fullwidth letters",
+			body: "ｆｏｒｎａｘ",
+			want: "****",
+		},
+		{
+			name: "Substrings are not matched",
+			body: "kerfuffles",
+			want: "kerfuffles",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := list.Check(tt.body)
+			if got.Body != tt.want {
+				t.Errorf("Check() body = %q, want %q", got.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	chain := Chain{
+		NewWordList(DefaultWords, Mask),
+		NewRegexRule(regexp.MustCompile(`(?i)buy followers`), Reject, "spam"),
+		NewRegexRule(regexp.MustCompile(`(?i)crypto`), Flag, "possible scam"),
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		wantAction  Action
+		wantReasons []string
+	}{
+		{
+			name:       "Allowed",
+			body:       "hello world",
+			wantAction: Allow,
+		},
+		{
+			name:       "Masked",
+			body:       "hello fornax",
+			wantAction: Mask,
+		},
+		{
+			name:        "Flagged",
+			body:        "fornax loves crypto",
+			wantAction:  Flag,
+			wantReasons: []string{"possible scam"},
+		},
+		{
+			name:        "Rejected stops the chain",
+			body:        "buy followers and crypto",
+			wantAction:  Reject,
+			wantReasons: []string{"spam"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chain.Check(tt.body)
+			if got.Action != tt.wantAction {
+				t.Errorf("Check() action = %v, want %v", got.Action, tt.wantAction)
+			}
+			if len(got.Reasons) != len(tt.wantReasons) {
+				t.Fatalf("Check() reasons = %v, want %v", got.Reasons, tt.wantReasons)
+			}
+			for i := range got.Reasons {
+				if got.Reasons[i] != tt.wantReasons[i] {
+					t.Errorf("Check() reasons = %v, want %v", got.Reasons, tt.wantReasons)
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineReload(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "moderation.json")
+	wordsPath := filepath.Join(dir, "words.txt")
+
+	writeFile(t, configPath, `{"word_lists": [{"path": "words.txt", "action": "mask"}]}`)
+	writeFile(t, wordsPath, "# blocked words\nkerfuffle\n")
+
+	pipeline, err := LoadPipeline(configPath)
+	if err != nil {
+		t.Fatalf("LoadPipeline() error = %v", err)
+	}
+	if got := pipeline.Check("a sharbert").Body; got != "a sharbert" {
+		t.Errorf("Check() before reload = %q, want %q", got, "a sharbert")
+	}
+
+	writeFile(t, wordsPath, "kerfuffle\nsharbert\n")
+	if err := pipeline.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := pipeline.Check("a sharbert").Body; got != "a ****" {
+		t.Errorf("Check() after reload = %q, want %q", got, "a ****")
+	}
+
+	writeFile(t, configPath, `{"rules": [{"pattern": "(", "action": "reject"}]}`)
+	if err := pipeline.Reload(); err == nil {
+		t.Errorf("Reload() with an invalid rule should fail")
+	}
+	if got := pipeline.Check("a sharbert").Body; got != "a ****" {
+		t.Errorf("Check() after failed reload = %q, want %q", got, "a ****")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,59 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+)
+
+var leetspeak = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'9': 'g',
+	'@': 'a',
+	'$': 's',
+	'|': 'l',
+	'+': 't',
+}
+
+// homoglyphs maps Cyrillic and Greek letters to the Latin letters they are
+// usually mistaken for.
+var homoglyphs = map[rune]rune{
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's',
+	'і': 'i', 'ј': 'j', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v',
+	'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+// Normalize folds text into the form word lists are matched against: lower
+// case, fullwidth and lookalike letters mapped to ASCII, leetspeak decoded,
+// and anything that still isn't a letter or digit dropped.
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		// Fullwidth ASCII variants, e.g. ｋｅｒｆｕｆｆｌｅ.
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r = unicode.ToLower(r - 0xFEE0)
+		}
+		if mapped, ok := homoglyphs[r]; ok {
+			r = mapped
+		}
+		if mapped, ok := leetspeak[r]; ok {
+			r = mapped
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isLeetspeak(r rune) bool {
+	_, ok := leetspeak[r]
+	return ok
+}
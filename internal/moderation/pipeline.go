@@ -0,0 +1,206 @@
+package moderation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultWords are masked when no moderation config is provided.
+var DefaultWords = []string{"kerfuffle", "sharbert", "fornax"}
+
+type config struct {
+	WordLists []struct {
+		Path   string `json:"path"`
+		Action string `json:"action"`
+	} `json:"word_lists"`
+	Rules []struct {
+		Pattern string `json:"pattern"`
+		Action  string `json:"action"`
+		Reason  string `json:"reason"`
+	} `json:"rules"`
+}
+
+// Pipeline is the moderation chain used by the server. Its rules can be
+// reloaded from disk while requests keep using the previous chain.
+type Pipeline struct {
+	path  string
+	chain atomic.Pointer[Chain]
+
+	mu       sync.Mutex
+	modTimes map[string]time.Time
+}
+
+// NewPipeline returns a pipeline that always uses chain.
+func NewPipeline(chain Chain) *Pipeline {
+	p := &Pipeline{}
+	p.chain.Store(&chain)
+	return p
+}
+
+// LoadPipeline builds a pipeline from the JSON config file at path. Word list
+// paths in the config are relative to the config file.
+func LoadPipeline(path string) (*Pipeline, error) {
+	p := &Pipeline{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Pipeline) Check(body string) Result {
+	return p.chain.Load().Check(body)
+}
+
+// Reload rereads the config and word lists. If anything fails to load the
+// current chain is kept.
+func (p *Pipeline) Reload() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	chain, files, err := loadChain(p.path)
+	if err != nil {
+		return err
+	}
+
+	modTimes, err := statFiles(files)
+	if err != nil {
+		return err
+	}
+
+	p.chain.Store(&chain)
+	p.modTimes = modTimes
+	return nil
+}
+
+// Watch reloads the pipeline whenever the config or one of its word lists
+// changes on disk, checking every interval until ctx is cancelled.
+func (p *Pipeline) Watch(ctx context.Context, interval time.Duration) {
+	if p.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !p.changed() {
+			continue
+		}
+		if err := p.Reload(); err != nil {
+			log.Printf("Error reloading moderation rules: %s", err)
+			continue
+		}
+		log.Printf("Reloaded moderation rules from %s", p.path)
+	}
+}
+
+func (p *Pipeline) changed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for path, modTime := range p.modTimes {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadChain(path string) (Chain, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read moderation config: %w", err)
+	}
+
+	cfg := config{}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse moderation config: %w", err)
+	}
+
+	chain := Chain{}
+	files := []string{path}
+	dir := filepath.Dir(path)
+
+	for _, list := range cfg.WordLists {
+		action, err := ParseAction(list.Action)
+		if err != nil {
+			return nil, nil, err
+		}
+		listPath := list.Path
+		if !filepath.IsAbs(listPath) {
+			listPath = filepath.Join(dir, listPath)
+		}
+		words, err := readWordList(listPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		chain = append(chain, NewWordList(words, action))
+		files = append(files, listPath)
+	}
+
+	for _, rule := range cfg.Rules {
+		action, err := ParseAction(rule.Action)
+		if err != nil {
+			return nil, nil, err
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid moderation rule %q: %w", rule.Pattern, err)
+		}
+		chain = append(chain, NewRegexRule(pattern, action, rule.Reason))
+	}
+
+	return chain, files, nil
+}
+
+// readWordList reads one word per line, skipping blank lines and # comments.
+func readWordList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open word list: %w", err)
+	}
+	defer file.Close()
+
+	words := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read word list: %w", err)
+	}
+	return words, nil
+}
+
+func statFiles(paths []string) (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[path] = info.ModTime()
+	}
+	return modTimes, nil
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     Limit
+}
+
+// MemoryStore keeps buckets in process memory. Limits only hold per
+// process, so it suits a single instance or tests.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// pruneInterval is how often full buckets are dropped. A full bucket is the
+// same as no bucket, so dropping them only saves memory.
+const pruneInterval = time.Minute
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastPrune) >= pruneInterval {
+		s.prune(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), updatedAt: now}
+		s.buckets[key] = b
+	}
+	b.tokens = limit.refill(b.tokens, now.Sub(b.updatedAt))
+	b.updatedAt = now
+	b.limit = limit
+
+	if b.tokens < 1 {
+		return limit.NewResult(false, b.tokens), nil
+	}
+	b.tokens--
+	return limit.NewResult(true, b.tokens), nil
+}
+
+func (s *MemoryStore) prune(now time.Time) {
+	for key, b := range s.buckets {
+		if b.limit.refill(b.tokens, now.Sub(b.updatedAt)) >= float64(b.limit.Requests) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastPrune = now
+}
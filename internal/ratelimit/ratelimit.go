@@ -0,0 +1,119 @@
+// Package ratelimit throttles requests with token buckets. Each key gets a
+// bucket that holds up to Limit.Requests tokens and refills evenly over
+// Limit.Per; a request takes one token or is refused.
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Limit allows Requests requests per Per, in bursts of up to Requests.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// rate is how many tokens the bucket regains per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// refill returns how many tokens a bucket that held tokens has after elapsed.
+func (l Limit) refill(tokens float64, elapsed time.Duration) float64 {
+	return min(float64(l.Requests), tokens+elapsed.Seconds()*l.rate())
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request would be allowed. It
+	// is zero while tokens remain.
+	RetryAfter time.Duration
+}
+
+// NewResult describes a bucket left with tokens after a request was allowed
+// or refused. It's meant for Store implementations.
+func (l Limit) NewResult(allowed bool, tokens float64) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     l.Requests,
+		Remaining: max(int(math.Floor(tokens)), 0),
+		Reset:     l.timeToRefill(tokens, float64(l.Requests)),
+	}
+	if tokens < 1 {
+		result.RetryAfter = l.timeToRefill(tokens, 1)
+	}
+	return result
+}
+
+func (l Limit) timeToRefill(tokens, target float64) time.Duration {
+	if tokens >= target {
+		return 0
+	}
+	return time.Duration((target - tokens) / l.rate() * float64(time.Second))
+}
+
+// Store keeps the buckets. Take refills the bucket for key, then takes a
+// token from it if one is available.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// KeyFunc picks the bucket a request counts against, such as the caller's
+// user ID or IP address.
+type KeyFunc func(r *http.Request) string
+
+// Limiter is middleware that applies limits to handlers.
+type Limiter struct {
+	store Store
+	key   KeyFunc
+}
+
+func NewLimiter(store Store, key KeyFunc) *Limiter {
+	return &Limiter{store: store, key: key}
+}
+
+// Handler limits next to limit per key. Routes with the same name share
+// buckets, so name should be unique unless that's intended. Responses carry
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, and
+// refused requests get a 429 with Retry-After.
+//
+// If the store fails, the request is let through rather than taking the
+// API down with it.
+func (l *Limiter) Handler(name string, limit Limit, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, err := l.store.Take(r.Context(), name+":"+l.key(r), limit)
+		if err != nil {
+			log.Printf("Error checking rate limit: %s", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(max(seconds(result.RetryAfter), 1)))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"Too many requests, try again later"}`))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// seconds rounds d up to whole seconds, as the headers expect.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
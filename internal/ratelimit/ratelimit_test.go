@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Requests: 3, Per: 3 * time.Second}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		result, _ := store.Take(ctx, "a", limit)
+		if !result.Allowed || result.Remaining != i {
+			t.Fatalf("Take() = %+v, want allowed with %d remaining", result, i)
+		}
+	}
+
+	result, _ := store.Take(ctx, "a", limit)
+	if result.Allowed {
+		t.Fatalf("Take() on an empty bucket should not be allowed")
+	}
+	if result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+		t.Errorf("Take() RetryAfter = %v, Reset = %v, want 1s and 3s", result.RetryAfter, result.Reset)
+	}
+
+	if result, _ := store.Take(ctx, "b", limit); !result.Allowed {
+		t.Errorf("Take() for another key should be allowed")
+	}
+
+	now = now.Add(time.Second)
+	if result, _ := store.Take(ctx, "a", limit); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("Take() after refilling one token = %+v, want allowed with 0 remaining", result)
+	}
+
+	now = now.Add(time.Hour)
+	if result, _ := store.Take(ctx, "a", limit); result.Remaining != 2 {
+		t.Errorf("Take() after a long wait = %+v, want the bucket capped at 3 tokens", result)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return Result{}, errors.New("store unavailable")
+}
+
+func TestLimiterHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	key := func(r *http.Request) string { return r.RemoteAddr }
+	limit := Limit{Requests: 1, Per: time.Minute}
+
+	limiter := NewLimiter(NewMemoryStore(), key)
+	handler := limiter.Handler("test", limit, next)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first request: status = %v, want %v", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("RateLimit-Limit"); got != "1" {
+		t.Errorf("RateLimit-Limit = %q, want %q", got, "1")
+	}
+	if got := rec.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want %q", got, "0")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status = %v, want %v", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want %q", got, "60")
+	}
+
+	// Routes with a different name have their own buckets.
+	rec = httptest.NewRecorder()
+	limiter.Handler("other", limit, next).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("other route: status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	NewLimiter(failingStore{}, key).Handler("test", limit, next).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("failing store: status = %v, want the request let through", rec.Code)
+	}
+}
@@ -0,0 +1,145 @@
+package trending
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Window is a sliding period that trends are computed over. Each window is
+// compared with the window of the same length right before it.
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+var Windows = []Window{
+	{Name: "1h", Duration: time.Hour},
+	{Name: "24h", Duration: 24 * time.Hour},
+	{Name: "7d", Duration: 7 * 24 * time.Hour},
+}
+
+// TagCount is how often a tag was used in the current and previous window.
+type TagCount struct {
+	Tag      string
+	Current  int64
+	Previous int64
+}
+
+type Trend struct {
+	Tag           string  `json:"tag"`
+	Count         int64   `json:"count"`
+	PreviousCount int64   `json:"previous_count"`
+	Velocity      float64 `json:"velocity"`
+}
+
+// CountFunc counts tag usage since previousStart, split at currentStart.
+type CountFunc func(ctx context.Context, currentStart, previousStart time.Time) ([]TagCount, error)
+
+// Aggregator periodically recomputes trending tags for every window and keeps
+// the results in memory, so reading them never touches the database.
+type Aggregator struct {
+	count    CountFunc
+	interval time.Duration
+
+	mu        sync.RWMutex
+	trends    map[string][]Trend
+	updatedAt time.Time
+}
+
+func NewAggregator(count CountFunc, interval time.Duration) *Aggregator {
+	return &Aggregator{
+		count:    count,
+		interval: interval,
+		trends:   map[string][]Trend{},
+	}
+}
+
+// Run refreshes the trends immediately and then on every interval until ctx
+// is cancelled.
+func (a *Aggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.Refresh(ctx); err != nil {
+			log.Printf("Error refreshing trending hashtags: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Aggregator) Refresh(ctx context.Context) error {
+	now := time.Now().UTC()
+	trends := make(map[string][]Trend, len(Windows))
+
+	for _, window := range Windows {
+		currentStart := now.Add(-window.Duration)
+		previousStart := currentStart.Add(-window.Duration)
+
+		counts, err := a.count(ctx, currentStart, previousStart)
+		if err != nil {
+			return err
+		}
+		trends[window.Name] = rank(counts)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.trends = trends
+	a.updatedAt = now
+	return nil
+}
+
+// Top returns up to n trends for the named window and when they were last
+// computed. ok is false if the window is unknown or no refresh has finished.
+func (a *Aggregator) Top(window string, n int) (trends []Trend, updatedAt time.Time, ok bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	all, ok := a.trends[window]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	if n > len(all) {
+		n = len(all)
+	}
+	return all[:n], a.updatedAt, true
+}
+
+// rank orders tags by velocity: growth over the previous window relative to
+// how used the tag already was. A tag jumping from 2 to 20 uses outranks one
+// going from 500 to 520, even though the latter has more uses.
+func rank(counts []TagCount) []Trend {
+	trends := []Trend{}
+	for _, count := range counts {
+		if count.Current == 0 {
+			continue
+		}
+		trends = append(trends, Trend{
+			Tag:           count.Tag,
+			Count:         count.Current,
+			PreviousCount: count.Previous,
+			Velocity:      float64(count.Current-count.Previous) / float64(count.Previous+1),
+		})
+	}
+
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Velocity != trends[j].Velocity {
+			return trends[i].Velocity > trends[j].Velocity
+		}
+		if trends[i].Count != trends[j].Count {
+			return trends[i].Count > trends[j].Count
+		}
+		return trends[i].Tag < trends[j].Tag
+	})
+
+	return trends
+}
@@ -0,0 +1,59 @@
+package trending
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	counts := []TagCount{
+		{Tag: "steady", Current: 520, Previous: 500},
+		{Tag: "rising", Current: 20, Previous: 2},
+		{Tag: "new", Current: 3, Previous: 0},
+		{Tag: "gone", Current: 0, Previous: 40},
+	}
+
+	got := rank(counts)
+
+	wantTags := []string{"rising", "new", "steady"}
+	if len(got) != len(wantTags) {
+		t.Fatalf("rank() returned %d trends, want %d", len(got), len(wantTags))
+	}
+	for i, tag := range wantTags {
+		if got[i].Tag != tag {
+			t.Errorf("rank()[%d].Tag = %v, want %v", i, got[i].Tag, tag)
+		}
+	}
+}
+
+func TestAggregatorTop(t *testing.T) {
+	count := func(ctx context.Context, currentStart, previousStart time.Time) ([]TagCount, error) {
+		return []TagCount{
+			{Tag: "a", Current: 5},
+			{Tag: "b", Current: 4},
+			{Tag: "c", Current: 3},
+		}, nil
+	}
+	aggregator := NewAggregator(count, time.Minute)
+
+	if _, _, ok := aggregator.Top("24h", 2); ok {
+		t.Fatalf("Top() before Refresh() should not be ok")
+	}
+
+	if err := aggregator.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	trends, _, ok := aggregator.Top("24h", 2)
+	if !ok {
+		t.Fatalf("Top() after Refresh() should be ok")
+	}
+	if len(trends) != 2 || trends[0].Tag != "a" || trends[1].Tag != "b" {
+		t.Errorf("Top() = %v, want tags a and b", trends)
+	}
+
+	if _, _, ok := aggregator.Top("90d", 2); ok {
+		t.Errorf("Top() for an unknown window should not be ok")
+	}
+}
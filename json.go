@@ -35,3 +35,14 @@ func respondWithJSON(w http.ResponseWriter, code int, payload any) {
 	w.WriteHeader(code)
 	w.Write(data)
 }
+
+// maxRequestBodyBytes is far more than any JSON body the API accepts. It
+// keeps handlers from decoding, and hashing, arbitrarily large input.
+const maxRequestBodyBytes = 1 << 20
+
+func middlewareLimitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
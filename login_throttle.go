@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+)
+
+// Failed logins are counted per email address and per IP. An account is
+// locked out after a handful of failures; an IP gets more room because many
+// users can share one.
+var (
+	accountLockoutPolicy = auth.LockoutPolicy{Threshold: 5, Base: 30 * time.Second, Max: time.Hour}
+	ipLockoutPolicy      = auth.LockoutPolicy{Threshold: 20, Base: 30 * time.Second, Max: time.Hour}
+)
+
+const (
+	auditLoginFailed    = "login_failed"
+	auditLoginLockedOut = "login_locked_out"
+	auditLoginSucceeded = "login_succeeded"
+)
+
+func emailThrottleKey(email string) string {
+	return "email:" + email
+}
+
+func ipThrottleKey(r *http.Request) string {
+	return "ip:" + clientIP(r)
+}
+
+// checkLoginLockout responds with 429 and returns false if either the email
+// or the client's IP is locked out.
+func (cfg *apiConfig) checkLoginLockout(w http.ResponseWriter, r *http.Request, email string) bool {
+	lockouts, err := cfg.db.GetActiveLoginLockouts(r.Context(), []string{emailThrottleKey(email), ipThrottleKey(r)})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check login attempts", err)
+		return false
+	}
+
+	var lockedUntil time.Time
+	for _, lockout := range lockouts {
+		if lockout.Time.After(lockedUntil) {
+			lockedUntil = lockout.Time
+		}
+	}
+	if lockedUntil.IsZero() {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(time.Until(lockedUntil).Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	respondWithError(w, http.StatusTooManyRequests, "Too many failed login attempts, try again later", nil)
+	return false
+}
+
+// recordLoginFailure counts a failed attempt against the email and IP and
+// locks them out once their policy says so. userID is set when the email
+// belongs to an account, for the audit log.
+func (cfg *apiConfig) recordLoginFailure(r *http.Request, email string, userID uuid.NullUUID) {
+	ctx := r.Context()
+	cfg.audit(ctx, auditLoginFailed, userID, r, email)
+
+	for key, policy := range map[string]auth.LockoutPolicy{
+		emailThrottleKey(email): accountLockoutPolicy,
+		ipThrottleKey(r):        ipLockoutPolicy,
+	} {
+		failures, err := cfg.db.RecordLoginFailure(ctx, key)
+		if err != nil {
+			log.Printf("Error recording login failure: %s", err)
+			continue
+		}
+
+		lockout := policy.LockoutFor(failures)
+		if lockout == 0 {
+			continue
+		}
+		err = cfg.db.SetLoginLockedUntil(ctx, database.SetLoginLockedUntilParams{
+			Key:         key,
+			LockedUntil: sql.NullTime{Time: time.Now().UTC().Add(lockout), Valid: true},
+		})
+		if err != nil {
+			log.Printf("Error locking out %s: %s", key, err)
+			continue
+		}
+		cfg.audit(ctx, auditLoginLockedOut, userID, r, key+" for "+lockout.String())
+	}
+}
+
+// recordLoginSuccess clears the email's failures; the IP's count keeps
+// decaying on its own so one good login can't reset a password spray.
+func (cfg *apiConfig) recordLoginSuccess(r *http.Request, user database.User) {
+	err := cfg.db.ClearLoginFailures(r.Context(), emailThrottleKey(user.Email))
+	if err != nil {
+		log.Printf("Error clearing login failures: %s", err)
+	}
+	cfg.audit(r.Context(), auditLoginSucceeded, uuid.NullUUID{UUID: user.ID, Valid: true}, r, "")
+}
+
+// audit records a security event. Failing to write it is logged but doesn't
+// fail the request.
+func (cfg *apiConfig) audit(ctx context.Context, event string, userID uuid.NullUUID, r *http.Request, detail string) {
+	err := cfg.db.CreateAuditLogEntry(ctx, database.CreateAuditLogEntryParams{
+		Event:     event,
+		UserID:    userID,
+		IpAddress: clientIP(r),
+		Detail:    detail,
+	})
+	if err != nil {
+		log.Printf("Error writing audit log: %s", err)
+	}
+}
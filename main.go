@@ -1,24 +1,41 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/pderyuga/chirpy-go/internal/auth"
 	"github.com/pderyuga/chirpy-go/internal/database"
+	"github.com/pderyuga/chirpy-go/internal/mailer"
+	"github.com/pderyuga/chirpy-go/internal/moderation"
+	"github.com/pderyuga/chirpy-go/internal/ratelimit"
+	"github.com/pderyuga/chirpy-go/internal/trending"
 
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
+	dbConn         *sql.DB
 	db             *database.Queries
 	platform       string
-	jwtSecret      string
+	tokens         auth.TokenConfig
+	mailer         mailer.Mailer
+	baseURL        string
 	polkaKey       string
+	trending       *trending.Aggregator
+	moderation     *moderation.Pipeline
+	auth           *auth.Authenticator
+	limiter        *ratelimit.Limiter
+	passwordPolicy auth.PasswordPolicy
 }
 
 func main() {
@@ -42,50 +59,222 @@ func main() {
 		log.Fatal("JWT_SECRET must be set")
 	}
 
+	// Without a signing key, tokens are signed with the shared JWT_SECRET.
+	// JWT_VERIFY_KEYS lists keys that are being rotated out and should
+	// still be accepted.
+	jwtKeys := auth.NewHMACKeySet(jwtSecret)
+	jwtSigningKey := os.Getenv("JWT_SIGNING_KEY")
+	if jwtSigningKey != "" {
+		verifyKeys := []string{}
+		for _, path := range strings.Split(os.Getenv("JWT_VERIFY_KEYS"), ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				verifyKeys = append(verifyKeys, path)
+			}
+		}
+		var err error
+		jwtKeys, err = auth.LoadKeySet(jwtSigningKey, verifyKeys)
+		if err != nil {
+			log.Fatalf("Error loading JWT keys: %s", err)
+		}
+	}
+
+	tokens := auth.TokenConfig{
+		Keys:     jwtKeys,
+		Audience: os.Getenv("JWT_AUDIENCE"),
+	}
+	if tokens.Audience == "" {
+		tokens.Audience = "chirpy-api"
+	}
+	if leewayString := os.Getenv("JWT_LEEWAY"); leewayString != "" {
+		leeway, err := time.ParseDuration(leewayString)
+		if err != nil {
+			log.Fatalf("Invalid JWT_LEEWAY: %s", err)
+		}
+		tokens.Leeway = leeway
+	}
+
+	// Hashes made with other parameters are upgraded as users log in.
+	passwordParams := *auth.PasswordParams
+	for name, param := range map[string]*uint32{
+		"ARGON2_MEMORY":      &passwordParams.Memory,
+		"ARGON2_ITERATIONS":  &passwordParams.Iterations,
+		"ARGON2_SALT_LENGTH": &passwordParams.SaltLength,
+		"ARGON2_KEY_LENGTH":  &passwordParams.KeyLength,
+	} {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.ParseUint(value, 10, 32)
+			if err != nil || parsed == 0 {
+				log.Fatalf("Invalid %s: %s", name, value)
+			}
+			*param = uint32(parsed)
+		}
+	}
+	if value := os.Getenv("ARGON2_PARALLELISM"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 8)
+		if err != nil || parsed == 0 {
+			log.Fatalf("Invalid ARGON2_PARALLELISM: %s", value)
+		}
+		passwordParams.Parallelism = uint8(parsed)
+	}
+	auth.PasswordParams = &passwordParams
+
+	passwordPolicy := auth.DefaultPasswordPolicy
+	for name, rule := range map[string]*int{
+		"PASSWORD_MIN_LENGTH":   &passwordPolicy.MinLength,
+		"PASSWORD_MAX_LENGTH":   &passwordPolicy.MaxLength,
+		"PASSWORD_MIN_STRENGTH": &passwordPolicy.MinStrength,
+	} {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				log.Fatalf("Invalid %s: %s", name, value)
+			}
+			*rule = parsed
+		}
+	}
+	if breachedPasswordsFile := os.Getenv("BREACHED_PASSWORDS_FILE"); breachedPasswordsFile != "" {
+		breached, err := auth.LoadBreachedPasswords(breachedPasswordsFile)
+		if err != nil {
+			log.Fatalf("Error loading breached passwords: %s", err)
+		}
+		passwordPolicy.Breached = breached
+	}
+
 	polkaKey := os.Getenv("POLKA_KEY")
 	if platform == "" {
 		log.Fatal("POLKA_KEY must be set")
 	}
 
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:" + port
+	}
+
+	// Mail is written to the log unless an SMTP server is configured.
+	var appMailer mailer.Mailer = mailer.NewLogMailer(os.Stdout)
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		smtpPort := os.Getenv("SMTP_PORT")
+		if smtpPort == "" {
+			smtpPort = "587"
+		}
+		appMailer = mailer.NewSMTPMailer(smtpHost, smtpPort, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+	}
+
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		log.Fatalf("Error opening database: %s", err)
 	}
 	dbQueries := database.New(db)
 
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), dbQueries, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	moderationPipeline := moderation.NewPipeline(moderation.Chain{
+		moderation.NewWordList(moderation.DefaultWords, moderation.Mask),
+	})
+	moderationConfig := os.Getenv("MODERATION_CONFIG")
+	if moderationConfig != "" {
+		moderationPipeline, err = moderation.LoadPipeline(moderationConfig)
+		if err != nil {
+			log.Fatalf("Error loading moderation rules: %s", err)
+		}
+		go moderationPipeline.Watch(context.Background(), 5*time.Second)
+	}
+
 	apiCfg := apiConfig{
 		fileserverHits: atomic.Int32{},
+		dbConn:         db,
 		db:             dbQueries,
 		platform:       platform,
-		jwtSecret:      jwtSecret,
+		tokens:         tokens,
 		polkaKey:       polkaKey,
+		moderation:     moderationPipeline,
+		mailer:         appMailer,
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
+		passwordPolicy: passwordPolicy,
+	}
+	apiCfg.auth = auth.NewAuthenticator(tokens, apiCfg.lookupAccount)
+	apiCfg.trending = trending.NewAggregator(apiCfg.countHashtags, time.Minute)
+	go apiCfg.trending.Run(context.Background())
+
+	// Buckets live in memory unless RATE_LIMIT_STORE is "postgres", which
+	// is needed for limits to hold across replicas.
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	switch os.Getenv("RATE_LIMIT_STORE") {
+	case "", "memory":
+	case "postgres":
+		rateLimitStore = dbRateLimitStore{db: dbQueries}
+		go apiCfg.pruneRateLimits(context.Background(), createUserRateLimit.Per)
+	default:
+		log.Fatalf("Invalid RATE_LIMIT_STORE: %s", os.Getenv("RATE_LIMIT_STORE"))
 	}
+	apiCfg.limiter = ratelimit.NewLimiter(rateLimitStore, apiCfg.rateLimitKey)
 
 	mux := http.NewServeMux()
 	mux.Handle("/app/", http.StripPrefix("/app", apiCfg.middlewareMetricsInc(http.FileServer(http.Dir(filepathRoot)))))
 
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
+	mux.HandleFunc("GET /.well-known/jwks.json", apiCfg.handlerJWKS)
 
-	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
+	mux.Handle("POST /api/login", apiCfg.limiter.Handler("login", loginRateLimit, http.HandlerFunc(apiCfg.handlerLogin)))
+	mux.Handle("POST /api/login/mfa", apiCfg.limiter.Handler("login", loginRateLimit, http.HandlerFunc(apiCfg.handlerLoginMFA)))
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
-	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
+	mux.HandleFunc("POST /api/password/forgot", apiCfg.handlerForgotPassword)
+	mux.HandleFunc("POST /api/password/reset", apiCfg.handlerResetPassword)
+	mux.Handle("POST /api/revoke", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersWrite, apiCfg.handlerRevoke)))
 
-	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerEditUser)
+	mux.Handle("GET /api/sessions", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersRead, apiCfg.handlerListSessions)))
+	mux.Handle("DELETE /api/sessions/{sessionID}", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersWrite, apiCfg.handlerRevokeSession)))
+	mux.Handle("POST /api/sessions/revoke-all", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersWrite, apiCfg.handlerRevokeAllSessions)))
+
+	mux.Handle("POST /api/users", apiCfg.limiter.Handler("create_user", createUserRateLimit, http.HandlerFunc(apiCfg.handlerCreateUser)))
+	mux.HandleFunc("GET /api/users/verify", apiCfg.handlerVerifyEmail)
+	mux.Handle("POST /api/users/verify/resend", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersWrite, apiCfg.handlerResendVerification)))
+	mux.Handle("PUT /api/users", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersWrite, apiCfg.handlerEditUser)))
+	mux.Handle("PATCH /api/users/me", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersWrite, apiCfg.handlerUpdateUser)))
+	mux.Handle("GET /api/users/me/mentions", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersRead, apiCfg.handlerGetMyMentions)))
+	mux.Handle("POST /api/users/me/mfa/totp", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersWrite, apiCfg.handlerEnrollTOTP)))
+	mux.Handle("POST /api/users/me/mfa/totp/confirm", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersWrite, apiCfg.handlerConfirmTOTP)))
+	mux.Handle("DELETE /api/users/me/mfa/totp", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersWrite, apiCfg.handlerDisableTOTP)))
+	mux.Handle("POST /api/users/{userID}/follow", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersWrite, apiCfg.handlerFollowUser)))
+	mux.Handle("DELETE /api/users/{userID}/follow", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersWrite, apiCfg.handlerUnfollowUser)))
 
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerUpgradeUser)
 
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetChirps)
-	mux.HandleFunc("GET /api/chirps/{chirpId}", apiCfg.handlerGetChirpById)
-	mux.HandleFunc("DELETE /api/chirps/{chirpId}", apiCfg.handlerDeleteChirp)
+	mux.Handle("POST /api/chirps", apiCfg.limiter.Handler("create_chirp", createChirpRateLimit, apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeChirpsWrite, apiCfg.handlerCreateChirp))))
+	mux.Handle("GET /api/chirps", apiCfg.auth.Optional(apiCfg.handlerGetChirps))
+	mux.Handle("GET /api/chirps/search", apiCfg.auth.Optional(apiCfg.handlerSearchChirps))
+	mux.Handle("GET /api/chirps/{chirpId}", apiCfg.auth.Optional(apiCfg.handlerGetChirpById))
+	mux.Handle("GET /api/chirps/{chirpId}/thread", apiCfg.auth.Optional(apiCfg.handlerGetChirpThread))
+	mux.Handle("DELETE /api/chirps/{chirpId}", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeChirpsWrite, apiCfg.handlerDeleteChirp)))
+	mux.Handle("POST /api/chirps/{chirpId}/like", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeChirpsWrite, apiCfg.handlerLikeChirp)))
+	mux.Handle("DELETE /api/chirps/{chirpId}/like", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeChirpsWrite, apiCfg.handlerUnlikeChirp)))
+	mux.Handle("POST /api/chirps/{chirpId}/rechirp", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeChirpsWrite, apiCfg.handlerRechirp)))
+	mux.Handle("DELETE /api/chirps/{chirpId}/rechirp", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeChirpsWrite, apiCfg.handlerUndoRechirp)))
+	mux.Handle("POST /api/chirps/{chirpId}/report", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeChirpsWrite, apiCfg.handlerReportChirp)))
+
+	mux.Handle("GET /api/timeline", apiCfg.auth.RequireRole(auth.RoleUser, auth.RequireScope(auth.ScopeUsersRead, apiCfg.handlerGetTimeline)))
+	mux.Handle("GET /api/hashtags/{tag}/chirps", apiCfg.auth.Optional(apiCfg.handlerGetHashtagChirps))
+	mux.HandleFunc("GET /api/trending", apiCfg.handlerGetTrending)
+
+	mux.Handle("GET /admin/metrics", apiCfg.auth.RequireRole(auth.RoleAdmin, apiCfg.handlerMetrics))
+	mux.Handle("POST /admin/reset", apiCfg.auth.RequireRole(auth.RoleAdmin, apiCfg.handlerReset))
+	mux.Handle("PUT /admin/users/{userID}/role", apiCfg.auth.RequireRole(auth.RoleAdmin, apiCfg.handlerSetUserRole))
 
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.Handle("GET /admin/moderation/reports", apiCfg.auth.RequireRole(auth.RoleModerator, apiCfg.handlerListReports))
+	mux.Handle("POST /admin/moderation/reports/{reportId}/approve", apiCfg.auth.RequireRole(auth.RoleModerator, apiCfg.handlerApproveReport))
+	mux.Handle("POST /admin/moderation/reports/{reportId}/hide", apiCfg.auth.RequireRole(auth.RoleModerator, apiCfg.handlerHideReportedChirp))
+	mux.Handle("POST /admin/moderation/reports/{reportId}/delete", apiCfg.auth.RequireRole(auth.RoleModerator, apiCfg.handlerDeleteReportedChirp))
+	mux.Handle("POST /admin/moderation/users/{userID}/suspend", apiCfg.auth.RequireRole(auth.RoleModerator, apiCfg.handlerSuspendUser))
+	mux.Handle("DELETE /admin/moderation/users/{userID}/suspend", apiCfg.auth.RequireRole(auth.RoleModerator, apiCfg.handlerUnsuspendUser))
 
 	server := &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: apiCfg.limiter.Handler("default", defaultRateLimit, middlewareLimitBody(mux)),
 	}
 	log.Printf("Server running port %s\n", port)
 	log.Printf("Serving files from directory %s\n", filepathRoot)
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+// mfaChallengeTokens is the access token config with its own audience, so a
+// challenge token can't be used as an access token or the other way round.
+func (cfg *apiConfig) mfaChallengeTokens() auth.TokenConfig {
+	tokens := cfg.tokens
+	tokens.Audience = cfg.tokens.Audience + "/mfa"
+	return tokens
+}
+
+func (cfg *apiConfig) respondWithMFAChallenge(w http.ResponseWriter, user database.User) {
+	type challengeResponse struct {
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+	}
+
+	mfaToken, err := auth.MakeJWT(auth.Claims{
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
+	}, cfg.mfaChallengeTokens(), mfaChallengeTTL)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating MFA challenge", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, challengeResponse{
+		MFARequired: true,
+		MFAToken:    mfaToken,
+	})
+}
+
+// handlerLoginMFA completes a login that returned an MFA challenge, using
+// either a code from the authenticator app or a recovery code.
+func (cfg *apiConfig) handlerLoginMFA(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	claims, err := auth.ValidateJWT(params.MFAToken, cfg.mfaChallengeTokens())
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), claims.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token", err)
+		return
+	}
+	if claims.TokenVersion != user.TokenVersion || !user.TotpEnabled {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token", nil)
+		return
+	}
+	if user.IsSuspended {
+		respondWithError(w, http.StatusForbidden, "Account is suspended", nil)
+		return
+	}
+
+	// Codes are short, so guessing them is throttled like passwords.
+	if !cfg.checkLoginLockout(w, r, user.Email) {
+		return
+	}
+
+	ok, err := cfg.checkSecondFactor(r, user, params.Code, params.RecoveryCode)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check code", err)
+		return
+	}
+	if !ok {
+		cfg.recordLoginFailure(r, user.Email, uuid.NullUUID{UUID: user.ID, Valid: true})
+		respondWithError(w, http.StatusUnauthorized, "Incorrect code", nil)
+		return
+	}
+
+	cfg.recordLoginSuccess(r, user)
+	cfg.completeLogin(w, r, user)
+}
+
+// checkSecondFactor accepts either a TOTP code, which can only be used once,
+// or a recovery code, which is consumed.
+func (cfg *apiConfig) checkSecondFactor(r *http.Request, user database.User, code, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		consumed, err := cfg.db.ConsumeRecoveryCode(r.Context(), database.ConsumeRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(recoveryCode)),
+		})
+		return consumed == 1, err
+	}
+
+	step, ok := auth.ValidateTOTP(user.TotpSecret.String, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	// Only the first use of a code counts, even if it's still in its
+	// validity window.
+	used, err := cfg.db.UseTOTPStep(r.Context(), database.UseTOTPStepParams{
+		ID:           user.ID,
+		TotpLastStep: step,
+	})
+	return used == 1, err
+}
+
+// handlerEnrollTOTP starts enrollment by generating a secret. It isn't used
+// for logins until the user proves their app has it at the confirm
+// endpoint, so a half-finished enrollment can't lock anyone out.
+func (cfg *apiConfig) handlerEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	type enrollResponse struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't find user", err)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate secret", err)
+		return
+	}
+
+	updated, err := cfg.db.SetPendingTOTPSecret(r.Context(), database.SetPendingTOTPSecretParams{
+		ID:         userID,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save secret", err)
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, enrollResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI("Chirpy", user.Email, secret),
+	})
+}
+
+// handlerConfirmTOTP turns on two-factor authentication once the user
+// enters a valid code, and returns their recovery codes. This is the only
+// time the recovery codes are shown.
+func (cfg *apiConfig) handlerConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code string `json:"code"`
+	}
+	type confirmResponse struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't find user", err)
+		return
+	}
+	if user.TotpEnabled {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+	if !user.TotpSecret.Valid {
+		respondWithError(w, http.StatusBadRequest, "Start enrollment first", nil)
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(user.TotpSecret.String, params.Code, time.Now())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Incorrect code", nil)
+		return
+	}
+
+	recoveryCodes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate recovery codes", err)
+		return
+	}
+	codeHashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		codeHashes = append(codeHashes, auth.HashToken(code))
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.EnableTOTP(r.Context(), database.EnableTOTPParams{
+		ID:           userID,
+		TotpLastStep: step,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't enable two-factor authentication", err)
+		return
+	}
+	err = qtx.DeleteRecoveryCodes(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save recovery codes", err)
+		return
+	}
+	err = qtx.CreateRecoveryCodes(r.Context(), database.CreateRecoveryCodesParams{
+		UserID:     userID,
+		CodeHashes: codeHashes,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save recovery codes", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, confirmResponse{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// handlerDisableTOTP turns two-factor authentication off. It takes a current
+// code or a recovery code, so a stolen access token alone isn't enough.
+func (cfg *apiConfig) handlerDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't find user", err)
+		return
+	}
+	if !user.TotpEnabled {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication isn't enabled", nil)
+		return
+	}
+
+	// Wrong codes count towards the login lockout, as they do at
+	// /api/login/mfa.
+	if !cfg.checkLoginLockout(w, r, user.Email) {
+		return
+	}
+
+	ok, err := cfg.checkSecondFactor(r, user, params.Code, params.RecoveryCode)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check code", err)
+		return
+	}
+	if !ok {
+		cfg.recordLoginFailure(r, user.Email, uuid.NullUUID{UUID: user.ID, Valid: true})
+		respondWithError(w, http.StatusUnauthorized, "Incorrect code", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.DisableTOTP(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't disable two-factor authentication", err)
+		return
+	}
+	err = qtx.DeleteRecoveryCodes(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete recovery codes", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
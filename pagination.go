@@ -0,0 +1,131 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/database"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageCursor is the position of the last item on a page. Chirps are ordered
+// by (created_at, id) so the cursor stays stable when timestamps collide.
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type pageRequest struct {
+	Limit  int32
+	Cursor *pageCursor
+}
+
+func pageFromRequest(r *http.Request) (pageRequest, error) {
+	limit, err := limitFromRequest(r)
+	if err != nil {
+		return pageRequest{}, err
+	}
+	page := pageRequest{Limit: limit}
+
+	cursorString := r.URL.Query().Get("cursor")
+	if cursorString != "" {
+		cursor, err := decodeCursor(cursorString)
+		if err != nil {
+			return pageRequest{}, err
+		}
+		page.Cursor = &cursor
+	}
+
+	return page, nil
+}
+
+func limitFromRequest(r *http.Request) (int32, error) {
+	limitString := r.URL.Query().Get("limit")
+	if limitString == "" {
+		return defaultPageSize, nil
+	}
+
+	limit, err := strconv.Atoi(limitString)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("limit must be a positive integer")
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return int32(limit), nil
+}
+
+// queryLimit asks for one extra row so we know whether another page exists
+// without running a separate count query.
+func (p pageRequest) queryLimit() int32 {
+	return p.Limit + 1
+}
+
+func (p pageRequest) cursorCreatedAt() sql.NullTime {
+	if p.Cursor == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true}
+}
+
+func (p pageRequest) cursorID() uuid.NullUUID {
+	if p.Cursor == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := fmt.Sprintf("%d:%s", createdAt.UnixMicro(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	micros, idString, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return pageCursor{}, fmt.Errorf("invalid cursor")
+	}
+	unixMicro, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("invalid cursor")
+	}
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	return pageCursor{
+		CreatedAt: time.UnixMicro(unixMicro).UTC(),
+		ID:        id,
+	}, nil
+}
+
+// paginateChirps trims the extra row fetched by queryLimit and returns the
+// cursor for the next page, or an empty string on the last page.
+func paginateChirps(chirps []database.Chirp, page pageRequest) ([]database.Chirp, string) {
+	if chirps == nil {
+		return []database.Chirp{}, ""
+	}
+	if int32(len(chirps)) <= page.Limit {
+		return chirps, ""
+	}
+
+	chirps = chirps[:page.Limit]
+	last := chirps[len(chirps)-1]
+	return chirps, encodeCursor(last.CreatedAt, last.ID)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/database"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	id := uuid.New()
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		want    pageCursor
+		wantErr bool
+	}{
+		{
+			name:   "Round trip",
+			cursor: encodeCursor(createdAt, id),
+			want:   pageCursor{CreatedAt: createdAt, ID: id},
+		},
+		{
+			name:    "Not base64",
+			cursor:  "not a cursor!",
+			wantErr: true,
+		},
+		{
+			name:    "Missing separator",
+			cursor:  encode("1714566600123456"),
+			wantErr: true,
+		},
+		{
+			name:    "Tampered timestamp",
+			cursor:  encode("yesterday:" + id.String()),
+			wantErr: true,
+		},
+		{
+			name:    "Tampered ID",
+			cursor:  encode("1714566600123456:not-a-uuid"),
+			wantErr: true,
+		},
+		{
+			name:    "Empty",
+			cursor:  encode(""),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.cursor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeCursor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (!got.CreatedAt.Equal(tt.want.CreatedAt) || got.ID != tt.want.ID) {
+				t.Errorf("decodeCursor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginateChirps(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	chirp := func(offset time.Duration) database.Chirp {
+		return database.Chirp{ID: uuid.New(), CreatedAt: createdAt.Add(offset)}
+	}
+	// The last two chirps on the page tie on created_at, so only the ID
+	// tells them apart.
+	tied := []database.Chirp{chirp(0), chirp(time.Second), chirp(time.Second), chirp(2 * time.Second)}
+
+	tests := []struct {
+		name       string
+		chirps     []database.Chirp
+		limit      int32
+		wantLen    int
+		wantCursor *database.Chirp
+	}{
+		{
+			name:    "No rows",
+			chirps:  nil,
+			limit:   2,
+			wantLen: 0,
+		},
+		{
+			name:    "Fewer rows than the limit",
+			chirps:  tied[:1],
+			limit:   2,
+			wantLen: 1,
+		},
+		{
+			name:    "Exactly the limit is the last page",
+			chirps:  tied[:2],
+			limit:   2,
+			wantLen: 2,
+		},
+		{
+			name:       "One extra row means another page",
+			chirps:     tied[:3],
+			limit:      2,
+			wantLen:    2,
+			wantCursor: &tied[1],
+		},
+		{
+			name:       "Cursor points at the last row when timestamps tie",
+			chirps:     tied,
+			limit:      3,
+			wantLen:    3,
+			wantCursor: &tied[2],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cursor := paginateChirps(tt.chirps, pageRequest{Limit: tt.limit})
+			if got == nil || len(got) != tt.wantLen {
+				t.Fatalf("paginateChirps() returned %v chirps, want %d", got, tt.wantLen)
+			}
+
+			if tt.wantCursor == nil {
+				if cursor != "" {
+					t.Errorf("paginateChirps() cursor = %q, want none", cursor)
+				}
+				return
+			}
+			decoded, err := decodeCursor(cursor)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if !decoded.CreatedAt.Equal(tt.wantCursor.CreatedAt) || decoded.ID != tt.wantCursor.ID {
+				t.Errorf("paginateChirps() cursor = %v, want the position of %v", decoded, tt.wantCursor.ID)
+			}
+		})
+	}
+}
+
+func TestPageFromRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantLimit int32
+		wantErr   bool
+	}{
+		{"Default limit", "", defaultPageSize, false},
+		{"Limit is capped", "?limit=1000", maxPageSize, false},
+		{"Zero limit", "?limit=0", 0, true},
+		{"Non-numeric limit", "?limit=ten", 0, true},
+		{"Invalid cursor", "?cursor=bogus", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := pageFromRequest(httptest.NewRequest("GET", "/api/chirps"+tt.query, nil))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pageFromRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && page.Limit != tt.wantLimit {
+				t.Errorf("pageFromRequest() Limit = %v, want %v", page.Limit, tt.wantLimit)
+			}
+			if !tt.wantErr && page.queryLimit() != tt.wantLimit+1 {
+				t.Errorf("queryLimit() = %v, want %v", page.queryLimit(), tt.wantLimit+1)
+			}
+		})
+	}
+}
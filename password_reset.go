@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+	"github.com/pderyuga/chirpy-go/internal/mailer"
+)
+
+const passwordResetTTL = time.Hour
+
+// handlerForgotPassword emails a reset token if the address belongs to an
+// account. It always answers 202 and does the work in the background, so
+// neither the status nor the response time reveals whether it does.
+func (cfg *apiConfig) handlerForgotPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	email, err := mailer.NormalizeAddress(params.Email)
+	if err == nil {
+		go func() {
+			err := cfg.sendPasswordReset(context.WithoutCancel(r.Context()), email)
+			if err != nil {
+				log.Printf("Error sending password reset: %s", err)
+			}
+		}()
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (cfg *apiConfig) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := cfg.db.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := auth.MakeOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	// Only the newest token works.
+	err = cfg.db.DeletePasswordResetTokensForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	err = cfg.db.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().UTC().Add(passwordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	// The link opens reset-password.html from the file server, which posts
+	// the token and the new password to /api/password/reset.
+	link := cfg.baseURL + "/app/reset-password.html?token=" + url.QueryEscape(token)
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body:    fmt.Sprintf("Someone asked to reset the password for your Chirpy account. If it was you, choose a new password here:\n\n%s\n\nThe link expires in 1 hour. If you didn't ask for this, you can ignore this email.", link),
+	})
+}
+
+// handlerResetPassword sets a new password using an emailed token. Like any
+// password change, it logs the user out everywhere.
+func (cfg *apiConfig) handlerResetPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	// Checked before the token is consumed, so a rejected password doesn't
+	// use it up.
+	if !cfg.checkPasswordPolicy(w, params.Password) {
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	resetToken, err := qtx.ConsumePasswordResetToken(r.Context(), auth.HashToken(params.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "Invalid or already used reset token", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+	if resetToken.ExpiresAt.Before(time.Now()) {
+		// Commit so the expired token is gone either way.
+		tx.Commit()
+		respondWithError(w, http.StatusBadRequest, "Reset token has expired", nil)
+		return
+	}
+
+	err = qtx.SetUserPassword(r.Context(), database.SetUserPasswordParams{
+		ID:             resetToken.UserID,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+	_, err = qtx.IncrementTokenVersion(r.Context(), resetToken.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+	err = qtx.RevokeAllRefreshTokensForUser(r.Context(), resetToken.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke sessions", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
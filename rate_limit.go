@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+	"github.com/pderyuga/chirpy-go/internal/ratelimit"
+)
+
+// Every request counts against defaultRateLimit. Routes that create
+// accounts or content also have a tighter limit of their own.
+var (
+	defaultRateLimit     = ratelimit.Limit{Requests: 300, Per: time.Minute}
+	createUserRateLimit  = ratelimit.Limit{Requests: 5, Per: time.Hour}
+	createChirpRateLimit = ratelimit.Limit{Requests: 30, Per: time.Minute}
+	loginRateLimit       = ratelimit.Limit{Requests: 20, Per: time.Minute}
+)
+
+// rateLimitKey counts requests with a valid access token against the user,
+// and everything else against the client's IP. The token is only checked
+// for a user ID here; the auth middleware still does the full check.
+func (cfg *apiConfig) rateLimitKey(r *http.Request) string {
+	token, err := auth.GetBearerToken(r.Header)
+	if err == nil {
+		claims, err := auth.ValidateJWT(token, cfg.tokens)
+		if err == nil {
+			return "user:" + claims.UserID.String()
+		}
+	}
+	return "ip:" + clientIP(r)
+}
+
+// dbRateLimitStore keeps buckets in Postgres so limits hold across
+// replicas.
+type dbRateLimitStore struct {
+	db *database.Queries
+}
+
+func (s dbRateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	requests := float64(limit.Requests)
+	rate := requests / limit.Per.Seconds()
+
+	tokens, err := s.db.TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+		Key:      key,
+		Requests: requests,
+		Rate:     rate,
+	})
+	if err == nil {
+		return limit.NewResult(true, tokens), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return ratelimit.Result{}, err
+	}
+
+	// No row means the bucket was empty and left alone; read it back for
+	// the headers.
+	tokens, err = s.db.GetRateLimitTokens(ctx, database.GetRateLimitTokensParams{
+		Requests: requests,
+		Rate:     rate,
+		Key:      key,
+	})
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	return limit.NewResult(false, tokens), nil
+}
+
+// pruneRateLimits deletes buckets that have been idle long enough to be
+// full again, until ctx is cancelled. maxPer is the longest Per of any
+// limit in use.
+func (cfg *apiConfig) pruneRateLimits(ctx context.Context, maxPer time.Duration) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := cfg.db.DeleteIdleRateLimitBuckets(ctx, time.Now().UTC().Add(-maxPer))
+		if err != nil {
+			log.Printf("Error pruning rate limits: %s", err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+)
+
+// getOriginalChirp loads a chirp that is about to be rechirped or quoted.
+// Sharing a rechirp shares the chirp it points at, so references never chain.
+// A deleted chirp is reported as sql.ErrNoRows, like a missing one.
+func (cfg *apiConfig) getOriginalChirp(ctx context.Context, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := cfg.db.GetChirpById(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if chirp.RechirpOf.Valid {
+		chirp, err = cfg.db.GetChirpById(ctx, chirp.RechirpOf.UUID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if chirp.IsDeleted {
+		return database.Chirp{}, fmt.Errorf("chirp has been deleted: %w", sql.ErrNoRows)
+	}
+	return chirp, nil
+}
+
+func (cfg *apiConfig) handlerRechirp(w http.ResponseWriter, r *http.Request) {
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+	if !requireVerifiedEmail(w, r) {
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	original, err := cfg.getOriginalChirp(r.Context(), chirpId)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp to rechirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp to rechirp", err)
+		return
+	}
+	rechirpOf := uuid.NullUUID{UUID: original.ID, Valid: true}
+
+	// Rechirping twice is a no-op that returns the existing rechirp. The
+	// insert does nothing if the rechirp exists, even when two requests race.
+	status := http.StatusCreated
+	rechirp, err := cfg.db.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:    userID,
+		RechirpOf: rechirpOf,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		status = http.StatusOK
+		rechirp, err = cfg.db.GetRechirp(r.Context(), database.GetRechirpParams{
+			UserID:    userID,
+			RechirpOf: rechirpOf,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rechirp", err)
+		return
+	}
+
+	response, err := cfg.chirpResponse(r.Context(), rechirp, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	respondWithJSON(w, status, response)
+}
+
+func (cfg *apiConfig) handlerUndoRechirp(w http.ResponseWriter, r *http.Request) {
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// Like rechirping, undoing accepts the ID of a rechirp and acts on the
+	// chirp it points at.
+	rechirpOf := chirpId
+	chirp, err := cfg.db.GetChirpById(r.Context(), chirpId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't undo rechirp", err)
+		return
+	}
+	if err == nil && chirp.RechirpOf.Valid {
+		rechirpOf = chirp.RechirpOf.UUID
+	}
+
+	err = cfg.db.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:    userID,
+		RechirpOf: uuid.NullUUID{UUID: rechirpOf, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't undo rechirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,16 +1,59 @@
 package main
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
 )
 
+const refreshTokenTTL = 60 * 24 * time.Hour
+
+// startSession issues the first refresh token of a new family, which is
+// what logging in does.
+func startSession(r *http.Request, db *database.Queries, userID uuid.UUID) (string, error) {
+	return issueRefreshToken(r, db, userID, uuid.New(), time.Now().UTC().Add(refreshTokenTTL))
+}
+
+// issueRefreshToken creates a refresh token in the given family. Every token
+// in a family shares the first one's expiry, so rotating doesn't extend the
+// session: it has to be renewed by logging in again.
+// The request's user agent and IP are stored so the user can recognize the
+// session later.
+func issueRefreshToken(r *http.Request, db *database.Queries, userID, familyID uuid.UUID, expiresAt time.Time) (string, error) {
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		Token:     refreshToken,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		FamilyID:  familyID,
+		UserAgent: r.UserAgent(),
+		IpAddress: clientIP(r),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return refreshToken, nil
+}
+
+// handlerRefresh exchanges a refresh token for a new access token and a new
+// refresh token. Each refresh token can only be used once: presenting one
+// that was already rotated means it was copied, so the whole family is
+// revoked and both the thief and the legitimate client have to log in again.
 func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 	type refreshResponse struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
 	bearerToken, err := auth.GetBearerToken(r.Header)
@@ -24,6 +67,10 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
 		return
 	}
+	if refreshToken.ReplacedBy.Valid {
+		cfg.revokeRefreshTokenFamily(w, r, refreshToken.FamilyID)
+		return
+	}
 	if refreshToken.ExpiresAt.Before(time.Now()) {
 		respondWithError(w, http.StatusUnauthorized, "Expired refresh token", fmt.Errorf("Expired refresh token"))
 		return
@@ -40,34 +87,102 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
 		return
 	}
+	if user.IsSuspended {
+		respondWithError(w, http.StatusForbidden, "Account is suspended", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	newRefreshToken, err := issueRefreshToken(r, qtx, user.ID, refreshToken.FamilyID, refreshToken.ExpiresAt)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create refresh token", err)
+		return
+	}
+
+	// The update only matches if nobody rotated the token since we read it,
+	// so two concurrent refreshes can't both succeed.
+	replaced, err := qtx.MarkRefreshTokenReplaced(r.Context(), database.MarkRefreshTokenReplacedParams{
+		Token:      refreshToken.Token,
+		ReplacedBy: sql.NullString{String: newRefreshToken, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rotate refresh token", err)
+		return
+	}
+	if replaced == 0 {
+		tx.Rollback()
+		cfg.revokeRefreshTokenFamily(w, r, refreshToken.FamilyID)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
 
-	jwtToken, err := auth.MakeJWT(user.ID, cfg.jwtSecret, expiresIn)
+	jwtToken, err := auth.MakeJWT(auth.Claims{
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
+		Scopes:       auth.AllScopes,
+	}, cfg.tokens, expiresIn)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
 		return
 	}
 
 	response := refreshResponse{
-		Token: jwtToken,
+		Token:        jwtToken,
+		RefreshToken: newRefreshToken,
 	}
 
 	respondWithJSON(w, http.StatusOK, response)
 }
 
+func (cfg *apiConfig) revokeRefreshTokenFamily(w http.ResponseWriter, r *http.Request, familyID uuid.UUID) {
+	err := cfg.db.RevokeRefreshTokenFamily(r.Context(), familyID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke refresh tokens", err)
+		return
+	}
+	respondWithError(w, http.StatusUnauthorized, "Refresh token was already used", fmt.Errorf("reuse of rotated refresh token in family %s", familyID))
+}
+
+// handlerRevoke logs out the session the given refresh token belongs to. The
+// caller has to be authenticated as the token's owner. The whole family is
+// revoked, so it works with a token that was already rotated too.
 func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
-	type revokeResponse struct {
-		Token string `json:"token"`
+	type parameters struct {
+		RefreshToken string `json:"refresh_token"`
 	}
 
-	bearerToken, err := auth.GetBearerToken(r.Header)
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
 		return
 	}
 
-	_, err = cfg.db.RevokeRefreshToken(r.Context(), bearerToken)
+	revoked, err := cfg.db.RevokeRefreshToken(r.Context(), database.RevokeRefreshTokenParams{
+		UserID: userID,
+		Token:  params.RefreshToken,
+	})
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke refresh token", err)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find an active session for this refresh token", nil)
 		return
 	}
 
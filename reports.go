@@ -0,0 +1,81 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+)
+
+const (
+	reportStatusOpen     = "open"
+	reportStatusApproved = "approved"
+	reportStatusHidden   = "hidden"
+	reportStatusDeleted  = "deleted"
+)
+
+func (cfg *apiConfig) handlerReportChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Reason string `json:"reason"`
+	}
+
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	reason := strings.TrimSpace(params.Reason)
+	if reason == "" {
+		respondWithError(w, http.StatusBadRequest, "A reason is required", nil)
+		return
+	}
+	if len(reason) > 500 {
+		respondWithError(w, http.StatusBadRequest, "Reason is too long", nil)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpById(r.Context(), chirpId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp", err)
+		return
+	}
+	if chirp.IsDeleted {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", nil)
+		return
+	}
+
+	report, err := cfg.db.CreateReport(r.Context(), database.CreateReportParams{
+		ChirpID:    chirpId,
+		ReporterID: uuid.NullUUID{UUID: userID, Valid: true},
+		Reason:     reason,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusConflict, "You have already reported this chirp", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't report chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, report)
+}
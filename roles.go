@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+)
+
+// lookupAccount loads the role and suspension state the authentication
+// middleware checks on every request.
+func (cfg *apiConfig) lookupAccount(ctx context.Context, userID uuid.UUID) (auth.Account, error) {
+	user, err := cfg.db.GetUserById(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.Account{}, auth.ErrUnknownAccount
+	}
+	if err != nil {
+		return auth.Account{}, err
+	}
+	return auth.Account{
+		Role:          user.Role,
+		Suspended:     user.IsSuspended,
+		TokenVersion:  user.TokenVersion,
+		EmailVerified: user.IsEmailVerified,
+	}, nil
+}
+
+func (cfg *apiConfig) handlerSetUserRole(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Role string `json:"role"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if !auth.ValidRole(params.Role) {
+		respondWithError(w, http.StatusBadRequest, "Unknown role", nil)
+		return
+	}
+
+	// Admins can't demote themselves, so there is always at least one left.
+	if userID == auth.PrincipalFromContext(r.Context()).UserID {
+		respondWithError(w, http.StatusForbidden, "You can't change your own role", nil)
+		return
+	}
+
+	updated, err := cfg.db.SetUserRole(r.Context(), database.SetUserRoleParams{
+		ID:   userID,
+		Role: params.Role,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+)
+
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	type searchResult struct {
+		Chirp chirpResponse `json:"chirp"`
+		Rank  float32       `json:"rank"`
+		// Snippet is HTML: the escaped chirp text with matches in <mark>.
+		Snippet string `json:"snippet"`
+	}
+	type response struct {
+		Results    []searchResult `json:"results"`
+		NextCursor string         `json:"next_cursor,omitempty"`
+	}
+
+	viewerID := auth.PrincipalFromContext(r.Context()).UserID
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondWithError(w, http.StatusBadRequest, "Search query is required", nil)
+		return
+	}
+
+	authorID, err := authorIDFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid author ID", err)
+		return
+	}
+
+	since, err := timeFromRequest(r, "since")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	until, err := timeFromRequest(r, "until")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	limit, err := limitFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	offset, err := decodeSearchCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.db.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:      query,
+		AuthorID:   uuid.NullUUID{UUID: authorID, Valid: authorID != uuid.Nil},
+		Since:      since,
+		Until:      until,
+		PageSize:   limit + 1,
+		PageOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search chirps", err)
+		return
+	}
+
+	nextCursor := ""
+	if int32(len(rows)) > limit {
+		rows = rows[:limit]
+		nextCursor = encodeSearchCursor(offset + limit)
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, database.Chirp{
+			ID:        row.ID,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+			Body:      row.Body,
+			UserID:    row.UserID,
+			InReplyTo: row.InReplyTo,
+			IsDeleted: row.IsDeleted,
+			RechirpOf: row.RechirpOf,
+			QuoteOf:   row.QuoteOf,
+		})
+	}
+	chirpResponses, err := cfg.chirpResponses(r.Context(), chirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search chirps", err)
+		return
+	}
+
+	results := make([]searchResult, 0, len(rows))
+	for i, row := range rows {
+		results = append(results, searchResult{
+			Chirp:   chirpResponses[i],
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Results:    results,
+		NextCursor: nextCursor,
+	})
+}
+
+func timeFromRequest(r *http.Request, param string) (sql.NullTime, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+// Search results are ordered by rank rather than (created_at, id), so their
+// cursor is an opaque offset instead of a keyset position.
+func encodeSearchCursor(offset int32) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(int(offset))))
+}
+
+func decodeSearchCursor(cursor string) (int32, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.ParseInt(string(raw), 10, 32)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return int32(offset), nil
+}
@@ -0,0 +1,5 @@
+-- name: CreateAuditLogEntry :exec
+INSERT INTO audit_log (id, created_at, event, user_id, ip_address, detail)
+VALUES (
+    gen_random_uuid(), NOW(), $1, $2, $3, $4
+);
@@ -1,22 +1,105 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, quote_of)
 VALUES (
-    gen_random_uuid(), NOW(), NOW(), $1, $2
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4
 )
 RETURNING *;
 
--- name: GetChirps :many
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(), '', $1, $2
+)
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL DO NOTHING
+RETURNING *;
+
+-- name: GetRechirp :one
 SELECT * FROM chirps
-ORDER BY created_at ASC;
+WHERE user_id = $1 AND rechirp_of = $2;
 
--- name: GetChirpsForAuthorId :many
+-- name: GetChirpsByIds :many
 SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC;
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: GetChirpsPageAsc :many
+SELECT * FROM chirps
+WHERE NOT is_deleted
+AND NOT is_hidden
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetChirpsPageDesc :many
+SELECT * FROM chirps
+WHERE NOT is_deleted
+AND NOT is_hidden
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
 
 -- name: GetChirpById :one
 SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.in_reply_to AS id FROM chirps parent
+    WHERE parent.id = $1
+    UNION
+    SELECT c.in_reply_to FROM chirps c
+    JOIN ancestors a ON c.id = a.id
+)
+SELECT chirps.* FROM chirps
+WHERE chirps.id IN (SELECT id FROM ancestors)
+ORDER BY chirps.created_at ASC, chirps.id ASC;
+
+-- name: GetChirpDescendantsPage :many
+WITH RECURSIVE descendants AS (
+    SELECT c.id FROM chirps c
+    WHERE c.in_reply_to = sqlc.arg('chirp_id')
+    UNION
+    SELECT c.id FROM chirps c
+    JOIN descendants d ON c.in_reply_to = d.id
+)
+SELECT chirps.* FROM chirps
+WHERE chirps.id IN (SELECT id FROM descendants)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ChirpHasReplies :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps WHERE in_reply_to = sqlc.arg('chirp_id')::uuid
+);
+
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '', is_deleted = TRUE, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetChirpHidden :exec
+UPDATE chirps
+SET is_hidden = $2, updated_at = NOW()
+WHERE id = $1;
+
 -- name: DeleteChirp :exec
 DELETE FROM chirps WHERE id=$1;
+
+-- name: DeleteRechirp :exec
+DELETE FROM chirps
+WHERE user_id = $1 AND rechirp_of = $2;
+
+-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of = sqlc.arg('chirp_id')::uuid;
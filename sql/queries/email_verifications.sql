@@ -0,0 +1,19 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (token_hash, user_id, email, created_at, expires_at)
+VALUES (
+    $1, $2, $3, NOW(), $4
+);
+
+-- name: ConsumeEmailVerificationToken :one
+DELETE FROM email_verification_tokens
+WHERE token_hash = $1
+RETURNING *;
+
+-- name: MarkEmailVerified :execrows
+UPDATE users
+SET is_email_verified = TRUE, updated_at = NOW()
+WHERE id = $1 AND email = $2;
+
+-- name: DeleteEmailVerificationTokensForUser :exec
+DELETE FROM email_verification_tokens
+WHERE user_id = $1;
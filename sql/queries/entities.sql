@@ -0,0 +1,43 @@
+-- name: AddChirpHashtags :exec
+INSERT INTO chirp_hashtags (chirp_id, tag)
+SELECT sqlc.arg('chirp_id')::uuid, unnest(sqlc.arg('tags')::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: AddChirpMentions :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+SELECT sqlc.arg('chirp_id')::uuid, unnest(sqlc.arg('user_ids')::uuid[])
+ON CONFLICT DO NOTHING;
+
+-- name: GetUserIdsByEmails :many
+SELECT id FROM users
+WHERE email = ANY(sqlc.arg('emails')::text[]);
+
+-- name: GetUserIdsByHandles :many
+SELECT id FROM users
+WHERE handle = ANY(sqlc.arg('handles')::text[]);
+
+-- name: GetChirpsForHashtagPage :many
+SELECT c.* FROM chirps c
+JOIN chirp_hashtags h ON h.chirp_id = c.id
+WHERE h.tag = sqlc.arg('tag')
+AND NOT c.is_deleted
+AND NOT c.is_hidden
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (c.created_at, c.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetMentionsPage :many
+SELECT c.* FROM chirps c
+JOIN chirp_mentions m ON m.chirp_id = c.id
+WHERE m.user_id = sqlc.arg('user_id')
+AND NOT c.is_deleted
+AND NOT c.is_hidden
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (c.created_at, c.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg('page_size');
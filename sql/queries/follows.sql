@@ -0,0 +1,21 @@
+-- name: FollowUser :exec
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :exec
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetTimelinePage :many
+SELECT c.* FROM chirps c
+JOIN follows f ON f.followee_id = c.user_id
+WHERE f.follower_id = sqlc.arg('follower_id')
+AND NOT c.is_deleted
+AND NOT c.is_hidden
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (c.created_at, c.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg('page_size');
@@ -0,0 +1,24 @@
+-- name: RecordLoginFailure :one
+INSERT INTO login_failures (key, failures, last_failure_at, locked_until)
+VALUES ($1, 1, NOW(), NULL)
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+        WHEN login_failures.last_failure_at < NOW() - interval '24 hours' THEN 1
+        ELSE login_failures.failures + 1
+    END,
+    last_failure_at = NOW()
+RETURNING failures;
+
+-- name: SetLoginLockedUntil :exec
+UPDATE login_failures
+SET locked_until = $2
+WHERE key = $1;
+
+-- name: GetActiveLoginLockouts :many
+SELECT locked_until FROM login_failures
+WHERE key = ANY(sqlc.arg('keys')::text[])
+AND locked_until > NOW();
+
+-- name: ClearLoginFailures :exec
+DELETE FROM login_failures
+WHERE key = $1;
@@ -0,0 +1,31 @@
+-- name: SetPendingTOTPSecret :execrows
+UPDATE users
+SET totp_secret = $2, updated_at = NOW()
+WHERE id = $1 AND NOT totp_enabled;
+
+-- name: EnableTOTP :exec
+UPDATE users
+SET totp_enabled = TRUE, totp_last_step = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DisableTOTP :exec
+UPDATE users
+SET totp_enabled = FALSE, totp_secret = NULL, totp_last_step = 0, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1 AND totp_last_step < $2;
+
+-- name: CreateRecoveryCodes :exec
+INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at)
+SELECT sqlc.arg('user_id')::uuid, unnest(sqlc.arg('code_hashes')::text[]), NOW();
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM mfa_recovery_codes
+WHERE user_id = $1;
+
+-- name: ConsumeRecoveryCode :execrows
+DELETE FROM mfa_recovery_codes
+WHERE user_id = $1 AND code_hash = $2;
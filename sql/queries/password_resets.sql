@@ -0,0 +1,14 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (token_hash, user_id, created_at, expires_at)
+VALUES (
+    $1, $2, NOW(), $3
+);
+
+-- name: ConsumePasswordResetToken :one
+DELETE FROM password_reset_tokens
+WHERE token_hash = $1
+RETURNING *;
+
+-- name: DeletePasswordResetTokensForUser :exec
+DELETE FROM password_reset_tokens
+WHERE user_id = $1;
@@ -0,0 +1,17 @@
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+VALUES (sqlc.arg('key'), sqlc.arg('requests')::float8 - 1, NOW())
+ON CONFLICT (key) DO UPDATE
+SET tokens = LEAST(sqlc.arg('requests')::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at) * sqlc.arg('rate')::float8) - 1,
+    updated_at = NOW()
+WHERE LEAST(sqlc.arg('requests')::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at) * sqlc.arg('rate')::float8) >= 1
+RETURNING tokens;
+
+-- name: GetRateLimitTokens :one
+SELECT LEAST(sqlc.arg('requests')::float8, tokens + EXTRACT(EPOCH FROM NOW() - updated_at) * sqlc.arg('rate')::float8)::float8 AS tokens
+FROM rate_limit_buckets
+WHERE key = sqlc.arg('key');
+
+-- name: DeleteIdleRateLimitBuckets :exec
+DELETE FROM rate_limit_buckets
+WHERE updated_at < $1;
@@ -1,7 +1,7 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, user_agent, ip_address, last_used_at)
 VALUES (
-    $1, NOW(), NOW(), $2, NOW() + interval '60 days', NULL
+    $1, NOW(), NOW(), $2, $3, NULL, $4, $5, $6, NOW()
 )
 RETURNING *;
 
@@ -13,8 +13,46 @@ SELECT u.* FROM users u, refresh_tokens rt
 WHERE u.id = rt.user_id
 AND rt.token = $1;
 
--- name: RevokeRefreshToken :one
+-- name: RevokeAllRefreshTokensForUser :exec
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
-WHERE token = $1
-RETURNING *;
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: MarkRefreshTokenReplaced :execrows
+UPDATE refresh_tokens
+SET replaced_by = $2, last_used_at = NOW(), updated_at = NOW()
+WHERE token = $1 AND replaced_by IS NULL AND revoked_at IS NULL;
+
+-- name: RevokeRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id') AND revoked_at IS NULL
+AND family_id = (
+    SELECT rt.family_id FROM refresh_tokens rt
+    WHERE rt.token = sqlc.arg('token') AND rt.user_id = sqlc.arg('user_id')
+);
+
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: GetActiveSessions :many
+SELECT
+    rt.family_id AS id,
+    (SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = rt.family_id)::timestamp AS created_at,
+    rt.last_used_at,
+    rt.expires_at,
+    rt.user_agent,
+    rt.ip_address
+FROM refresh_tokens rt
+WHERE rt.user_id = $1
+AND rt.revoked_at IS NULL
+AND rt.replaced_by IS NULL
+AND rt.expires_at > NOW()
+ORDER BY rt.last_used_at DESC;
@@ -0,0 +1,27 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, chirp_id, reporter_id, reason, status)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3, 'open'
+)
+ON CONFLICT (chirp_id, reporter_id) WHERE status = 'open' DO NOTHING
+RETURNING *;
+
+-- name: GetReportById :one
+SELECT * FROM reports
+WHERE id = $1;
+
+-- name: GetReportsPage :many
+SELECT r.*, c.body AS chirp_body, c.user_id AS chirp_author_id FROM reports r
+JOIN chirps c ON c.id = r.chirp_id
+WHERE r.status = sqlc.arg('status')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (r.created_at, r.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY r.created_at ASC, r.id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ResolveReportsForChirp :exec
+UPDATE reports
+SET status = $2, resolved_by = $3, resolved_at = NOW(), updated_at = NOW()
+WHERE status = 'open' AND chirp_id = $1;
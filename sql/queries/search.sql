@@ -0,0 +1,22 @@
+-- name: SearchChirps :many
+SELECT
+    c.id, c.created_at, c.updated_at, c.body, c.user_id,
+    c.in_reply_to, c.is_deleted, c.rechirp_of, c.quote_of,
+    ts_rank(to_tsvector('english', c.body), query) AS rank,
+    -- The body is HTML-escaped first, so the <mark> tags are the only
+    -- markup in the snippet.
+    ts_headline(
+        'english',
+        replace(replace(replace(c.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        query,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2'
+    ) AS snippet
+FROM chirps c, websearch_to_tsquery('english', sqlc.arg('query')) query
+WHERE to_tsvector('english', c.body) @@ query
+AND NOT c.is_deleted
+AND NOT c.is_hidden
+AND (sqlc.narg('author_id')::uuid IS NULL OR c.user_id = sqlc.narg('author_id'))
+AND (sqlc.narg('since')::timestamp IS NULL OR c.created_at >= sqlc.narg('since'))
+AND (sqlc.narg('until')::timestamp IS NULL OR c.created_at < sqlc.narg('until'))
+ORDER BY rank DESC, c.created_at DESC, c.id DESC
+LIMIT sqlc.arg('page_size') OFFSET sqlc.arg('page_offset');
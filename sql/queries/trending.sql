@@ -0,0 +1,10 @@
+-- name: GetHashtagCounts :many
+SELECT h.tag,
+    COUNT(*) FILTER (WHERE c.created_at >= sqlc.arg('current_start')::timestamp) AS current_count,
+    COUNT(*) FILTER (WHERE c.created_at < sqlc.arg('current_start')::timestamp) AS previous_count
+FROM chirp_hashtags h
+JOIN chirps c ON c.id = h.chirp_id
+WHERE c.created_at >= sqlc.arg('previous_start')::timestamp
+AND NOT c.is_deleted
+AND NOT c.is_hidden
+GROUP BY h.tag;
@@ -3,7 +3,8 @@ INSERT INTO users (id, created_at, updated_at, email, hashed_password)
 VALUES (
     gen_random_uuid(), NOW(), NOW(), $1, $2
 )
-RETURNING id, created_at, updated_at, email, is_chirpy_red;
+RETURNING id, created_at, updated_at, email, is_chirpy_red, is_email_verified,
+    handle, display_name, bio, avatar_url;
 
 -- name: GetUserByEmail :one
 SELECT * FROM users
@@ -11,12 +12,64 @@ WHERE email=$1;
 
 -- name: EditUser :one
 UPDATE users
-SET email = $2, hashed_password = $3, updated_at = NOW()
+SET email = $2, hashed_password = $3, updated_at = NOW(),
+    is_email_verified = is_email_verified AND email = $2
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, is_chirpy_red;
+RETURNING id, created_at, updated_at, email, is_chirpy_red, is_email_verified,
+    handle, display_name, bio, avatar_url;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET email = COALESCE(sqlc.narg('email'), email),
+    hashed_password = COALESCE(sqlc.narg('hashed_password'), hashed_password),
+    handle = COALESCE(sqlc.narg('handle'), handle),
+    display_name = COALESCE(sqlc.narg('display_name'), display_name),
+    bio = COALESCE(sqlc.narg('bio'), bio),
+    avatar_url = COALESCE(sqlc.narg('avatar_url'), avatar_url),
+    is_email_verified = is_email_verified AND email = COALESCE(sqlc.narg('email'), email),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING id, created_at, updated_at, email, is_chirpy_red, is_email_verified,
+    handle, display_name, bio, avatar_url;
 
 -- name: UpgradeUser :one
 UPDATE users
 SET is_chirpy_red = TRUE, updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, is_chirpy_red;
+RETURNING id, created_at, updated_at, email, is_chirpy_red, is_email_verified,
+    handle, display_name, bio, avatar_url;
+
+-- name: SetUserSuspended :execrows
+UPDATE users
+SET is_suspended = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserRole :execrows
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserRoleByEmail :execrows
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE email = $1;
+
+-- name: SetUserPassword :exec
+UPDATE users
+SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: RehashUserPassword :execrows
+UPDATE users
+SET hashed_password = sqlc.arg('new_hash'), updated_at = NOW()
+WHERE id = sqlc.arg('id') AND hashed_password = sqlc.arg('old_hash');
+
+-- name: IncrementTokenVersion :one
+UPDATE users
+SET token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING token_version;
+
+-- name: GetUserById :one
+SELECT * FROM users
+WHERE id = $1;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- An expression index rather than a tsvector column, so queries selecting
+-- whole chirps don't read it. Searches must use the same expression.
+CREATE INDEX chirps_body_search_idx ON chirps USING GIN (to_tsvector('english', body));
+
+-- +goose Down
+DROP INDEX chirps_body_search_idx;
@@ -0,0 +1,33 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN is_suspended BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE chirps
+ADD COLUMN is_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    reporter_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX reports_status_created_at_id_idx ON reports (status, created_at, id);
+CREATE UNIQUE INDEX reports_open_chirp_id_reporter_id_idx ON reports (chirp_id, reporter_id)
+WHERE status = 'open';
+
+-- +goose Down
+DROP TABLE reports;
+
+ALTER TABLE chirps
+DROP COLUMN is_hidden;
+
+ALTER TABLE users
+DROP COLUMN is_suspended,
+DROP COLUMN is_admin;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'user'
+CHECK (role IN ('user', 'moderator', 'admin'));
+
+UPDATE users SET role = 'admin' WHERE is_admin;
+
+ALTER TABLE users
+DROP COLUMN is_admin;
+
+-- +goose Down
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users SET is_admin = TRUE WHERE role = 'admin';
+
+ALTER TABLE users
+DROP COLUMN role;
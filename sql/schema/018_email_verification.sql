@@ -0,0 +1,45 @@
+-- +goose Up
+-- Emails are now compared normalized. Addresses that only differ by case
+-- would end up as the same account, so they have to be merged by hand
+-- before this can run.
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT lower(trim(email)) FROM users
+        GROUP BY lower(trim(email))
+        HAVING count(*) > 1
+    ) THEN
+        RAISE EXCEPTION 'users have emails that only differ by case or whitespace';
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+UPDATE users SET email = lower(trim(email));
+
+CREATE UNIQUE INDEX users_email_lower_idx ON users (lower(email));
+
+ALTER TABLE users
+ADD COLUMN is_email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Accounts created before verification existed keep working.
+UPDATE users SET is_email_verified = TRUE;
+
+CREATE TABLE email_verification_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX email_verification_tokens_user_id_idx ON email_verification_tokens (user_id);
+
+-- +goose Down
+DROP TABLE email_verification_tokens;
+
+ALTER TABLE users
+DROP COLUMN is_email_verified;
+
+DROP INDEX users_email_lower_idx;
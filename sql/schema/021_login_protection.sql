@@ -0,0 +1,24 @@
+-- +goose Up
+-- Keys are "email:<address>" or "ip:<address>", so addresses that don't
+-- belong to an account are throttled the same way as ones that do.
+CREATE TABLE login_failures (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    event TEXT NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ip_address TEXT NOT NULL,
+    detail TEXT NOT NULL
+);
+
+CREATE INDEX audit_log_user_id_created_at_idx ON audit_log (user_id, created_at);
+
+-- +goose Down
+DROP TABLE audit_log;
+DROP TABLE login_failures;
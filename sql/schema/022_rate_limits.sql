@@ -0,0 +1,11 @@
+-- +goose Up
+-- Token buckets for the rate limiter, shared by every replica. tokens is
+-- what the bucket held at updated_at; refills are computed on the next use.
+CREATE TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE rate_limit_buckets;
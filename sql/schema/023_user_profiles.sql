@@ -0,0 +1,19 @@
+-- +goose Up
+-- An empty handle means the user hasn't picked one. Handles are stored
+-- lowercase, so the unique index also makes them case-insensitive.
+ALTER TABLE users
+ADD COLUMN handle TEXT NOT NULL DEFAULT '',
+ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX users_handle_idx ON users (handle) WHERE handle <> '';
+
+-- +goose Down
+DROP INDEX users_handle_idx;
+
+ALTER TABLE users
+DROP COLUMN avatar_url,
+DROP COLUMN bio,
+DROP COLUMN display_name,
+DROP COLUMN handle;
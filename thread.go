@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+)
+
+type threadNode struct {
+	chirpResponse
+	Replies []*threadNode `json:"replies"`
+}
+
+func (cfg *apiConfig) handlerGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Ancestors  []chirpResponse `json:"ancestors"`
+		Chirp      chirpResponse   `json:"chirp"`
+		Replies    []*threadNode   `json:"replies"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+	}
+
+	viewerID := auth.PrincipalFromContext(r.Context()).UserID
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpById(r.Context(), chirpId)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, err.Error(), err)
+		return
+	}
+
+	ancestors, err := cfg.db.GetChirpAncestors(r.Context(), chirpId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get thread", err)
+		return
+	}
+
+	descendants, err := cfg.db.GetChirpDescendantsPage(r.Context(), database.GetChirpDescendantsPageParams{
+		ChirpID:         chirpId,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageSize:        page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get thread", err)
+		return
+	}
+
+	descendants, nextCursor := paginateChirps(descendants, page)
+
+	// Load the whole thread in one batch so likes are fetched once.
+	thread := make([]database.Chirp, 0, len(ancestors)+1+len(descendants))
+	thread = append(thread, ancestors...)
+	thread = append(thread, chirp)
+	thread = append(thread, descendants...)
+
+	responses, err := cfg.chirpResponses(r.Context(), thread, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get thread", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Ancestors:  responses[:len(ancestors)],
+		Chirp:      responses[len(ancestors)],
+		Replies:    buildReplyTree(responses[len(ancestors)+1:]),
+		NextCursor: nextCursor,
+	})
+}
+
+// buildReplyTree nests a page of descendants under their parents. Replies
+// always sort after the chirp they reply to, so a parent is either earlier in
+// this page, the thread's root chirp, or on a previous page. The last two end
+// up at the top level; their in_reply_to lets clients attach them.
+func buildReplyTree(descendants []chirpResponse) []*threadNode {
+	roots := []*threadNode{}
+	nodes := make(map[uuid.UUID]*threadNode, len(descendants))
+
+	for _, chirp := range descendants {
+		node := &threadNode{chirpResponse: chirp, Replies: []*threadNode{}}
+		nodes[chirp.ID] = node
+
+		if chirp.InReplyTo == nil {
+			roots = append(roots, node)
+			continue
+		}
+		parent, ok := nodes[*chirp.InReplyTo]
+		if !ok {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Replies = append(parent.Replies, node)
+	}
+
+	return roots
+}
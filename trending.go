@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pderyuga/chirpy-go/internal/database"
+	"github.com/pderyuga/chirpy-go/internal/trending"
+)
+
+const (
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = 50
+)
+
+// countHashtags feeds the trending aggregator. It runs in the background,
+// never on the request path.
+func (cfg *apiConfig) countHashtags(ctx context.Context, currentStart, previousStart time.Time) ([]trending.TagCount, error) {
+	rows, err := cfg.db.GetHashtagCounts(ctx, database.GetHashtagCountsParams{
+		CurrentStart:  currentStart,
+		PreviousStart: previousStart,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]trending.TagCount, 0, len(rows))
+	for _, row := range rows {
+		counts = append(counts, trending.TagCount{
+			Tag:      row.Tag,
+			Current:  row.CurrentCount,
+			Previous: row.PreviousCount,
+		})
+	}
+	return counts, nil
+}
+
+func (cfg *apiConfig) handlerGetTrending(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Window    string           `json:"window"`
+		UpdatedAt time.Time        `json:"updated_at"`
+		Tags      []trending.Trend `json:"tags"`
+	}
+
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "24h"
+	}
+
+	limit := defaultTrendingLimit
+	limitString := r.URL.Query().Get("limit")
+	if limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 {
+			respondWithError(w, http.StatusBadRequest, "limit must be a positive integer", err)
+			return
+		}
+		if limit > maxTrendingLimit {
+			limit = maxTrendingLimit
+		}
+	}
+
+	validWindow := false
+	for _, known := range trending.Windows {
+		if known.Name == window {
+			validWindow = true
+		}
+	}
+	if !validWindow {
+		respondWithError(w, http.StatusBadRequest, "window must be one of 1h, 24h or 7d", nil)
+		return
+	}
+
+	tags, updatedAt, ok := cfg.trending.Top(window, limit)
+	if !ok {
+		respondWithError(w, http.StatusServiceUnavailable, "Trending hashtags are not available yet", nil)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Window:    window,
+		UpdatedAt: updatedAt,
+		Tags:      tags,
+	})
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pderyuga/chirpy-go/internal/auth"
+	"github.com/pderyuga/chirpy-go/internal/database"
+	"github.com/pderyuga/chirpy-go/internal/mailer"
+)
+
+// Handles only use characters that the mention parser treats as part of a
+// word, so "@handle," in a chirp resolves to the handle.
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxAvatarURLLength   = 2048
+)
+
+// handlerUpdateUser changes only the fields that are sent. Changing the
+// email or password takes the current password, and like any credential
+// change logs the user out everywhere else.
+func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email           *string `json:"email"`
+		Password        *string `json:"password"`
+		CurrentPassword string  `json:"current_password"`
+		Handle          *string `json:"handle"`
+		DisplayName     *string `json:"display_name"`
+		Bio             *string `json:"bio"`
+		AvatarURL       *string `json:"avatar_url"`
+	}
+
+	type updateUserResponse struct {
+		database.UpdateUserProfileRow
+		Token        string `json:"token,omitempty"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+	}
+
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	update := database.UpdateUserProfileParams{ID: userID}
+
+	changesCredentials := params.Email != nil || params.Password != nil
+	if changesCredentials && !cfg.checkCurrentPassword(w, r, user, params.CurrentPassword) {
+		return
+	}
+
+	email := user.Email
+	if params.Email != nil {
+		email, err = mailer.NormalizeAddress(*params.Email)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		update.Email = sql.NullString{String: email, Valid: true}
+	}
+
+	if params.Password != nil {
+		if !cfg.checkPasswordPolicy(w, *params.Password, email) {
+			return
+		}
+		hashedPassword, err := auth.HashPassword(*params.Password)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+			return
+		}
+		update.HashedPassword = sql.NullString{String: hashedPassword, Valid: true}
+	}
+
+	if params.Handle != nil {
+		// An empty handle removes it.
+		handle := strings.ToLower(strings.TrimPrefix(*params.Handle, "@"))
+		if handle != "" && !handlePattern.MatchString(handle) {
+			respondWithError(w, http.StatusBadRequest, "Handle must be 3 to 30 letters, digits or underscores", nil)
+			return
+		}
+		update.Handle = sql.NullString{String: handle, Valid: true}
+	}
+
+	if params.DisplayName != nil {
+		displayName := strings.TrimSpace(*params.DisplayName)
+		if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Display name must be at most %d characters", maxDisplayNameLength), nil)
+			return
+		}
+		update.DisplayName = sql.NullString{String: displayName, Valid: true}
+	}
+
+	if params.Bio != nil {
+		bio := strings.TrimSpace(*params.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Bio must be at most %d characters", maxBioLength), nil)
+			return
+		}
+		update.Bio = sql.NullString{String: bio, Valid: true}
+	}
+
+	if params.AvatarURL != nil {
+		avatarURL := strings.TrimSpace(*params.AvatarURL)
+		if avatarURL != "" && !validAvatarURL(avatarURL) {
+			respondWithError(w, http.StatusBadRequest, "Avatar URL must be an absolute https URL", nil)
+			return
+		}
+		update.AvatarUrl = sql.NullString{String: avatarURL, Valid: true}
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	updated, err := qtx.UpdateUserProfile(r.Context(), update)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, "Email or handle is already taken", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+
+	response := updateUserResponse{UpdateUserProfileRow: updated}
+
+	var tokenVersion int32
+	if changesCredentials {
+		tokenVersion, err = qtx.IncrementTokenVersion(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+			return
+		}
+		err = qtx.RevokeAllRefreshTokensForUser(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+			return
+		}
+		response.RefreshToken, err = startSession(r, qtx, userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	if changesCredentials {
+		response.Token, err = auth.MakeJWT(auth.Claims{
+			UserID:       userID,
+			TokenVersion: tokenVersion,
+			Scopes:       auth.AllScopes,
+		}, cfg.tokens, time.Hour)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating access token", err)
+			return
+		}
+	}
+
+	if params.Email != nil && !updated.IsEmailVerified {
+		err = cfg.sendVerificationEmail(r.Context(), userID, updated.Email)
+		if err != nil {
+			log.Printf("Error sending verification email: %s", err)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func validAvatarURL(raw string) bool {
+	if len(raw) > maxAvatarURLLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
+
+// checkCurrentPassword confirms a credential change with the user's current
+// password. Wrong guesses count towards the same lockout as logins, so a
+// stolen access token can't be used to guess the password here instead.
+func (cfg *apiConfig) checkCurrentPassword(w http.ResponseWriter, r *http.Request, user database.User, password string) bool {
+	if !cfg.checkLoginLockout(w, r, user.Email) {
+		return false
+	}
+
+	match, _, err := auth.CheckPasswordHash(password, user.HashedPassword)
+	if err != nil && !errors.Is(err, auth.ErrPasswordNotSet) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check password", err)
+		return false
+	}
+	if !match {
+		cfg.recordLoginFailure(r, user.Email, uuid.NullUUID{UUID: user.ID, Valid: true})
+		respondWithError(w, http.StatusForbidden, "Current password is incorrect", err)
+		return false
+	}
+	return true
+}
@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pderyuga/chirpy-go/internal/auth"
 	"github.com/pderyuga/chirpy-go/internal/database"
+	"github.com/pderyuga/chirpy-go/internal/mailer"
 )
 
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request) {
@@ -26,6 +29,16 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	email, err := mailer.NormalizeAddress(params.Email)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if !cfg.checkPasswordPolicy(w, params.Password, email) {
+		return
+	}
+
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
@@ -33,7 +46,7 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 	}
 
 	createUserParams := database.CreateUserParams{
-		Email:          params.Email,
+		Email:          email,
 		HashedPassword: hashedPassword,
 	}
 
@@ -43,32 +56,58 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The account exists even if the email can't be sent; the user can ask
+	// for another one.
+	err = cfg.sendVerificationEmail(r.Context(), user.ID, user.Email)
+	if err != nil {
+		log.Printf("Error sending verification email: %s", err)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	respondWithJSON(w, http.StatusCreated, user)
 }
 
+// handlerEditUser replaces the email and password. Like PATCH /api/users/me,
+// it takes the current password.
 func (cfg *apiConfig) handlerEditUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email           string `json:"email"`
+		Password        string `json:"password"`
+		CurrentPassword string `json:"current_password"`
+	}
+
+	type editUserResponse struct {
+		database.EditUserRow
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
-	bearerToken, err := auth.GetBearerToken(r.Header)
+	userID := auth.PrincipalFromContext(r.Context()).UserID
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
-	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+
+	currentUser, err := cfg.db.GetUserById(r.Context(), userID)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if !cfg.checkCurrentPassword(w, r, currentUser, params.CurrentPassword) {
 		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err = decoder.Decode(&params)
+	email, err := mailer.NormalizeAddress(params.Email)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if !cfg.checkPasswordPolicy(w, params.Password, email) {
 		return
 	}
 
@@ -80,18 +119,74 @@ func (cfg *apiConfig) handlerEditUser(w http.ResponseWriter, r *http.Request) {
 
 	editUserParams := database.EditUserParams{
 		ID:             userID,
-		Email:          params.Email,
+		Email:          email,
 		HashedPassword: hashedPassword,
 	}
 
-	user, err := cfg.db.EditUser(r.Context(), editUserParams)
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	user, err := qtx.EditUser(r.Context(), editUserParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	// Every edit sets a new password, which logs the user out everywhere:
+	// old access tokens stop matching the token version and old refresh
+	// tokens are revoked. The caller gets a fresh pair so they stay logged in.
+	tokenVersion, err := qtx.IncrementTokenVersion(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	err = qtx.RevokeAllRefreshTokensForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	refreshToken, err := startSession(r, qtx, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	// Changing the email address clears verification until the new address
+	// is confirmed.
+	if !user.IsEmailVerified {
+		err = cfg.sendVerificationEmail(r.Context(), userID, user.Email)
+		if err != nil {
+			log.Printf("Error sending verification email: %s", err)
+		}
+	}
+
+	jwtToken, err := auth.MakeJWT(auth.Claims{
+		UserID:       userID,
+		TokenVersion: tokenVersion,
+		Scopes:       auth.AllScopes,
+	}, cfg.tokens, time.Hour)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating access token", err)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	respondWithJSON(w, http.StatusOK, user)
+	respondWithJSON(w, http.StatusOK, editUserResponse{
+		EditUserRow:  user,
+		Token:        jwtToken,
+		RefreshToken: refreshToken,
+	})
 }
 
 func (cfg *apiConfig) handlerUpgradeUser(w http.ResponseWriter, r *http.Request) {
@@ -138,3 +233,24 @@ func (cfg *apiConfig) handlerUpgradeUser(w http.ResponseWriter, r *http.Request)
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// checkPasswordPolicy responds with 422 and every failed rule if password
+// isn't acceptable. userInputs are the user's own details, such as their
+// email address, which make for weak passwords.
+func (cfg *apiConfig) checkPasswordPolicy(w http.ResponseWriter, password string, userInputs ...string) bool {
+	type rejectedResponse struct {
+		Error      string                   `json:"error"`
+		Violations []auth.PasswordViolation `json:"violations"`
+	}
+
+	violations := cfg.passwordPolicy.Check(password, userInputs...)
+	if len(violations) == 0 {
+		return true
+	}
+
+	respondWithJSON(w, http.StatusUnprocessableEntity, rejectedResponse{
+		Error:      "Password doesn't meet the password policy",
+		Violations: violations,
+	})
+	return false
+}